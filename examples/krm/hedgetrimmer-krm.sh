@@ -0,0 +1,3 @@
+#!/bin/sh
+# kustomize runs exec functions without arguments, container functions need an image with this entrypoint.
+exec hedgetrimmer krm --log-level=error
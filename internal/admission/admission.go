@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 
+	pkgadmission "github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+	admissionv1 "k8s.io/api/admission/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -18,27 +22,104 @@ type AdmissionHandler interface {
 	VersionSupported(v string) bool
 }
 
+// SubResourceHandler is implemented by handlers of a subresource of their Kind, e.g. pods/ephemeralcontainers.
+type SubResourceHandler interface {
+	SubResource() string
+}
+
+// routeKey is the kind, followed by the subresource for subresource requests.
+func routeKey(kind, subResource string) string {
+	if subResource == "" {
+		return kind
+	}
+	return kind + "/" + subResource
+}
+
+func handlerKey(h AdmissionHandler) string {
+	if sh, ok := h.(SubResourceHandler); ok {
+		return routeKey(h.Kind(), sh.SubResource())
+	}
+	return h.Kind()
+}
+
 type OptionsFunc func(*Router) error
 
 func WithAdmissionHandlers(handlers ...AdmissionHandler) OptionsFunc {
 	return func(r *Router) error {
 		for _, h := range handlers {
-			handlers := r.handlers[h.Kind()]
-			r.handlers[h.Kind()] = append(handlers, h)
+			key := handlerKey(h)
+			r.handlers[key] = append(r.handlers[key], h)
+		}
+		return nil
+	}
+}
+
+func WithRecorders(recorders ...Recorder) OptionsFunc {
+	return func(r *Router) error {
+		r.recorders = append(r.recorders, recorders...)
+		return nil
+	}
+}
+
+// WithDefaultConfig sets the Config used in namespaces without a Container LimitRange, its Min and Max are enforced by
+// the mutator.
+func WithDefaultConfig(cfg *limitrange.Config) OptionsFunc {
+	return func(r *Router) error {
+		if cfg != nil {
+			if err := cfg.Validate(); err != nil {
+				return fmt.Errorf("invalid default config: %w", err)
+			}
+
+			// no API server admission enforces the Min and Max of the default Config
+			enforced := *cfg
+			enforced.EnforceMinMax = true
+			cfg = &enforced
 		}
+		r.defaultConfig = cfg
+		return nil
+	}
+}
+
+// WithDenyUnconfigured denies the creation of objects in namespaces without a Container LimitRange instead of allowing
+// them or applying the default Config. Other operations and subresources are never denied for it.
+func WithDenyUnconfigured(deny bool) OptionsFunc {
+	return func(r *Router) error {
+		r.denyUnconfigured = deny
+		return nil
+	}
+}
+
+// WithMessages replaces the default messages of the router.
+func WithMessages(messages *mutators.Messages) OptionsFunc {
+	return func(r *Router) error {
+		r.messages = messages
+		return nil
+	}
+}
+
+// WithDocumentationURL adds a link to the documentation to denials and warnings.
+func WithDocumentationURL(url string) OptionsFunc {
+	return func(r *Router) error {
+		r.documentationURL = url
 		return nil
 	}
 }
 
 type Router struct {
-	handlers    map[string][]AdmissionHandler
-	limitRanger LimitRanger
+	handlers         map[string][]AdmissionHandler
+	limitRanger      LimitRanger
+	recorders        []Recorder
+	defaultConfig    *limitrange.Config
+	denyUnconfigured bool
+	documentationURL string
+	messages         *mutators.Messages
 }
 
 func NewRouter(lr LimitRanger, opts ...OptionsFunc) (*Router, error) {
 	r := &Router{
 		handlers:    map[string][]AdmissionHandler{},
 		limitRanger: lr,
+		messages:    mutators.DefaultMessages(),
 	}
 
 	for _, opt := range opts {
@@ -50,6 +131,16 @@ func NewRouter(lr LimitRanger, opts ...OptionsFunc) (*Router, error) {
 	return r, nil
 }
 
+// Kinds returns the sorted kinds with a registered handler, subresource handlers are listed as Kind/subresource.
+func (r *Router) Kinds() []string {
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
 func (r *Router) SetupWithManager(m manager.Manager) {
 	m.GetWebhookServer().Register("/mutate", &webhook.Admission{Handler: r})
 }
@@ -61,9 +152,10 @@ func (r *Router) Handle(ctx context.Context, req admission.Request) admission.Re
 		kind = &req.Kind
 	}
 
-	handlers, ok := r.handlers[kind.Kind]
+	key := routeKey(kind.Kind, req.SubResource)
+	handlers, ok := r.handlers[key]
 	if !ok {
-		return admission.Allowed(fmt.Sprintf("no handlers for kind: %s", kind.Kind))
+		return admission.Allowed(fmt.Sprintf("no handlers for kind: %s", key))
 	}
 
 	var handler AdmissionHandler
@@ -75,11 +167,12 @@ func (r *Router) Handle(ctx context.Context, req admission.Request) admission.Re
 	}
 
 	if handler == nil {
-		return admission.Denied(fmt.Sprintf("no handlers for %s version %s", kind.Kind, kind.Version))
+		return admission.Denied(fmt.Sprintf("no handlers for %s version %s", key, kind.Version))
 	}
 
 	logr := log.FromContext(ctx,
 		"resource", req.Resource,
+		"subresource", req.SubResource,
 		"namespace", req.Namespace,
 		"name", req.Name,
 		"operation", req.Operation,
@@ -92,9 +185,55 @@ func (r *Router) Handle(ctx context.Context, req admission.Request) admission.Re
 	}
 
 	if cfg == nil {
-		return admission.Allowed(fmt.Sprintf("No container limit range in namespace: %s", req.Namespace))
+		unconfigured := r.messages.Render(mutators.MessageUnconfigured, mutators.MessageData{Namespace: req.Namespace})
+
+		// only creations are denied, updates such as finalizer removal or kubectl debug must not get objects stuck
+		if r.denyUnconfigured && req.Operation == admissionv1.Create && req.SubResource == "" {
+			resp := r.withDocumentation(admission.Denied(unconfigured))
+			r.record(ctx, req, resp)
+			return resp
+		}
+
+		if r.defaultConfig == nil {
+			return admission.Allowed(unconfigured)
+		}
+
+		cfg = r.defaultConfig
 	}
 
 	ctx = limitrange.WithMemoryConfig(ctx, cfg)
-	return handler.Handle(ctx, req)
+	ctx = pkgadmission.WithWarnings(ctx)
+	ctx = pkgadmission.WithNamespace(ctx, req.Namespace)
+
+	resp := handler.Handle(ctx, req)
+	resp.Warnings = append(resp.Warnings, pkgadmission.WarningsFromContext(ctx)...)
+	resp = r.withDocumentation(resp)
+	r.record(ctx, req, resp)
+
+	return resp
+}
+
+func (r *Router) record(ctx context.Context, req admission.Request, resp admission.Response) {
+	for _, recorder := range r.recorders {
+		recorder.Record(ctx, req, resp)
+	}
+}
+
+// withDocumentation links the documentation from denials and responses with warnings.
+func (r *Router) withDocumentation(resp admission.Response) admission.Response {
+	if r.documentationURL == "" {
+		return resp
+	}
+
+	see := fmt.Sprintf("see %s", r.documentationURL)
+
+	if !resp.Allowed && resp.Result != nil {
+		resp.Result.Message = fmt.Sprintf("%s, %s", resp.Result.Message, see)
+	}
+
+	if len(resp.Warnings) > 0 {
+		resp.Warnings = append(resp.Warnings, see)
+	}
+
+	return resp
 }
@@ -10,9 +10,11 @@ import (
 	"testing"
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -101,6 +103,30 @@ func TestWithAdmissonHandlers_AddHandler(t *testing.T) {
 	assert.Len(t, r.handlers, 1)
 }
 
+func TestRouterKinds(t *testing.T) {
+	t.Parallel()
+	r, err := NewRouter(&MockLimitRanger{}, WithAdmissionHandlers(&MockReplicaSetHandler{}, &MockDeploymentHandler{}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Deployment", "ReplicaSet"}, r.Kinds())
+}
+
+func TestSubResourceRouting(t *testing.T) {
+	t.Parallel()
+	r, err := NewRouter(&MockLimitRanger{lrc: &limitrange.Config{}}, WithAdmissionHandlers(&MockDeploymentHandler{}, &MockScaleHandler{}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Deployment", "Deployment/scale"}, r.Kinds())
+
+	kind := &metav1.GroupVersionKind{Kind: "Deployment", Version: "v1"}
+
+	resp := r.Handle(context.TODO(), admission.Request{AdmissionRequest: v1.AdmissionRequest{RequestKind: kind, SubResource: "scale"}})
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, []string{"scale"}, resp.Warnings, "the subresource handler serves the request")
+
+	resp = r.Handle(context.TODO(), admission.Request{AdmissionRequest: v1.AdmissionRequest{RequestKind: kind, SubResource: "status"}})
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Warnings, "subresources without a handler are allowed")
+}
+
 func TestWithAdmissionHandlers_AddDuplciateHandler(t *testing.T) {
 	t.Parallel()
 	mlr := &MockLimitRanger{}
@@ -185,6 +211,153 @@ func TestAllowObjects(t *testing.T) {
 	}
 }
 
+type MockRecorder struct {
+	responses []admission.Response
+}
+
+func (m *MockRecorder) Record(ctx context.Context, req admission.Request, resp admission.Response) {
+	m.responses = append(m.responses, resp)
+}
+
+func TestUnconfiguredNamespaces(t *testing.T) {
+	t.Parallel()
+
+	decoder := admission.NewDecoder(runtime.NewScheme())
+	defaultConfig := &limitrange.Config{HasDefaultLimit: true, DefaultLimit: resource.MustParse("1Gi")}
+
+	b, err := json.Marshal(&appsv1.Deployment{TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}})
+	assert.NoError(t, err)
+	newRequest := func(operation v1.Operation, subResource string) admission.Request {
+		return admission.Request{AdmissionRequest: v1.AdmissionRequest{
+			Kind:        metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Namespace:   "unconfigured",
+			Operation:   operation,
+			SubResource: subResource,
+			Object:      runtime.RawExtension{Raw: b},
+		}}
+	}
+
+	tests := []struct {
+		msg         string
+		opts        []OptionsFunc
+		req         admission.Request
+		wantAllowed bool
+		wantMutated bool
+		// wantRecorded is the number of recorded responses
+		wantRecorded int
+	}{
+		{
+			msg:         "Allowed unmodified by default",
+			req:         newRequest(v1.Create, ""),
+			wantAllowed: true,
+		},
+		{
+			msg:          "Default config applied",
+			opts:         []OptionsFunc{WithDefaultConfig(defaultConfig)},
+			req:          newRequest(v1.Create, ""),
+			wantAllowed:  true,
+			wantMutated:  true,
+			wantRecorded: 1,
+		},
+		{
+			msg:          "Denied when unconfigured namespaces are denied",
+			opts:         []OptionsFunc{WithDefaultConfig(defaultConfig), WithDenyUnconfigured(true)},
+			req:          newRequest(v1.Create, ""),
+			wantRecorded: 1,
+		},
+		{
+			msg:         "Updates are not denied",
+			opts:        []OptionsFunc{WithDenyUnconfigured(true)},
+			req:         newRequest(v1.Update, ""),
+			wantAllowed: true,
+		},
+		{
+			msg:          "Updates get the default config when unconfigured namespaces are denied",
+			opts:         []OptionsFunc{WithDefaultConfig(defaultConfig), WithDenyUnconfigured(true)},
+			req:          newRequest(v1.Update, ""),
+			wantAllowed:  true,
+			wantMutated:  true,
+			wantRecorded: 1,
+		},
+		{
+			msg:         "Subresources are not denied",
+			opts:        []OptionsFunc{WithDenyUnconfigured(true)},
+			req:         newRequest(v1.Update, "scale"),
+			wantAllowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		recorder := &MockRecorder{}
+		opts := append([]OptionsFunc{WithAdmissionHandlers(&MockDeploymentHandler{MockHandler{decoder: decoder}}, &MockScaleHandler{}), WithRecorders(recorder)}, test.opts...)
+		r, err := NewRouter(&MockLimitRanger{}, opts...)
+		assert.NoError(t, err, test.msg)
+
+		resp := r.Handle(context.Background(), test.req)
+		assert.Equal(t, test.wantAllowed, resp.Allowed, test.msg)
+		assert.Equal(t, test.wantMutated, len(resp.Patches) > 0, test.msg)
+		if assert.Len(t, recorder.responses, test.wantRecorded, test.msg) && test.wantRecorded > 0 {
+			assert.Equal(t, resp, recorder.responses[0], test.msg)
+		}
+	}
+
+	_, err = NewRouter(&MockLimitRanger{}, WithDefaultConfig(&limitrange.Config{
+		HasDefaultRequest: true,
+		DefaultRequest:    resource.MustParse("2Gi"),
+		HasDefaultLimit:   true,
+		DefaultLimit:      resource.MustParse("1Gi"),
+	}))
+	assert.Error(t, err, "invalid default config")
+}
+
+func TestDocumentationURL(t *testing.T) {
+	t.Parallel()
+
+	decoder := admission.NewDecoder(runtime.NewScheme())
+	b, err := json.Marshal(&appsv1.Deployment{TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"}})
+	assert.NoError(t, err)
+	req := admission.Request{AdmissionRequest: v1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Namespace: "unconfigured",
+		Operation: v1.Create,
+		Object:    runtime.RawExtension{Raw: b},
+	}}
+
+	r, err := NewRouter(&MockLimitRanger{},
+		WithAdmissionHandlers(&MockDeploymentHandler{MockHandler{decoder: decoder}}),
+		WithDenyUnconfigured(true),
+		WithDocumentationURL("https://runbooks.example.com/memory"),
+	)
+	assert.NoError(t, err)
+
+	resp := r.Handle(context.Background(), req)
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, "No container limit range in namespace: unconfigured, see https://runbooks.example.com/memory", resp.Result.Message)
+
+	allowed := r.withDocumentation(admission.Allowed("").WithWarnings("set a memory limit"))
+	assert.Equal(t, []string{"set a memory limit", "see https://runbooks.example.com/memory"}, allowed.Warnings)
+
+	assert.Empty(t, r.withDocumentation(admission.Allowed("")).Warnings)
+}
+
+func TestUnconfiguredMessageTemplate(t *testing.T) {
+	t.Parallel()
+
+	messages, err := mutators.NewMessages(map[string]string{mutators.MessageUnconfigured: "ask for a LimitRange in {{.Namespace}}"})
+	assert.NoError(t, err)
+
+	r, err := NewRouter(&MockLimitRanger{}, WithAdmissionHandlers(&MockDeploymentHandler{}), WithDenyUnconfigured(true), WithMessages(messages))
+	assert.NoError(t, err)
+
+	resp := r.Handle(context.Background(), admission.Request{AdmissionRequest: v1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Namespace: "unconfigured",
+		Operation: v1.Create,
+	}})
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, "ask for a LimitRange in unconfigured", resp.Result.Message)
+}
+
 type MockHandler struct {
 	decoder admission.Decoder
 }
@@ -265,3 +438,19 @@ func (r *MockReplicaSetHandler) mutate(rs *appsv1.ReplicaSet) *appsv1.ReplicaSet
 
 	return patched
 }
+
+type MockScaleHandler struct {
+	MockHandler
+}
+
+func (s *MockScaleHandler) Kind() string {
+	return "Deployment"
+}
+
+func (s *MockScaleHandler) SubResource() string {
+	return "scale"
+}
+
+func (s *MockScaleHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	return admission.Allowed("").WithWarnings("scale")
+}
@@ -0,0 +1,13 @@
+package admission
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Recorder observes the outcome of every request the Router passes to a handler and of the denials in unconfigured
+// namespaces. Implementations must not block.
+type Recorder interface {
+	Record(ctx context.Context, req admission.Request, resp admission.Response)
+}
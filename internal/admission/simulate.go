@@ -0,0 +1,235 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// maxSimulationBodyBytes matches the API server's limit on admission review bodies.
+const maxSimulationBodyBytes = 3 * 1024 * 1024
+
+type SimulationRequest struct {
+	// Namespace is used when the object does not set one.
+	Namespace string               `json:"namespace"`
+	Object    runtime.RawExtension `json:"object"`
+}
+
+type SimulationResponse struct {
+	Allowed  bool                  `json:"allowed"`
+	Message  string                `json:"message,omitempty"`
+	Warnings []string              `json:"warnings,omitempty"`
+	Patch    []jsonpatch.Operation `json:"patch,omitempty"`
+}
+
+// Authenticator validates the bearer token of a simulation request and returns the user it belongs to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, error)
+}
+
+// Authorizer returns an error unless the user is allowed the action described by the attributes.
+type Authorizer interface {
+	Authorize(ctx context.Context, user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes) error
+}
+
+// TokenReviewAuthenticator authenticates bearer tokens against the API server with a TokenReview.
+type TokenReviewAuthenticator struct {
+	client kubernetes.Interface
+}
+
+func NewTokenReviewAuthenticator(client kubernetes.Interface) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{client: client}
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, error) {
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, err
+	}
+
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, fmt.Errorf("token not authenticated: %s", review.Status.Error)
+	}
+
+	return review.Status.User, nil
+}
+
+// SubjectAccessReviewAuthorizer authorizes users against the API server with a SubjectAccessReview.
+type SubjectAccessReviewAuthorizer struct {
+	client kubernetes.Interface
+}
+
+func NewSubjectAccessReviewAuthorizer(client kubernetes.Interface) *SubjectAccessReviewAuthorizer {
+	return &SubjectAccessReviewAuthorizer{client: client}
+}
+
+func (a *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes) error {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	review, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	if !review.Status.Allowed {
+		return fmt.Errorf("user %q cannot %s %s in namespace %q: %s", user.Username, attributes.Verb, attributes.Resource, attributes.Namespace, review.Status.Reason)
+	}
+
+	return nil
+}
+
+// Simulator serves admission decisions for arbitrary objects through the Router as dry-run requests, without any
+// side effect. Callers must present a bearer token accepted by the Authenticator and be allowed by the Authorizer to
+// create the object in its namespace.
+type Simulator struct {
+	router        *Router
+	authenticator Authenticator
+	authorizer    Authorizer
+}
+
+func NewSimulator(r *Router, authenticator Authenticator, authorizer Authorizer) *Simulator {
+	return &Simulator{router: r, authenticator: authenticator, authorizer: authorizer}
+}
+
+func (s *Simulator) SetupWithManager(m manager.Manager) {
+	m.GetWebhookServer().Register("/simulate", s)
+}
+
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logr := log.FromContext(r.Context()).WithName("simulate")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := s.authenticator.Authenticate(r.Context(), token)
+	if err != nil {
+		logr.Info("simulation request not authenticated", "error", err.Error())
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sr := SimulationRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSimulationBodyBytes)).Decode(&sr); err != nil {
+		http.Error(w, fmt.Sprintf("invalid simulation request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	req, err := simulationAdmissionRequest(sr, user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.authorizer.Authorize(r.Context(), user, authorizationv1.ResourceAttributes{
+		Namespace: req.Namespace,
+		Verb:      "create",
+		Group:     req.Resource.Group,
+		Version:   req.Resource.Version,
+		Resource:  req.Resource.Resource,
+	}); err != nil {
+		logr.Info("simulation request not authorized", "error", err.Error())
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	resp := s.router.Handle(r.Context(), req)
+
+	out := SimulationResponse{
+		Allowed:  resp.Allowed,
+		Warnings: resp.Warnings,
+		Patch:    resp.Patches,
+	}
+	if resp.Result != nil {
+		out.Message = resp.Result.Message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		logr.Error(err, "failed to encode simulation response")
+	}
+}
+
+func simulationAdmissionRequest(sr SimulationRequest, user authenticationv1.UserInfo) (admission.Request, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(sr.Object.Raw); err != nil {
+		return admission.Request{}, fmt.Errorf("invalid object: %w", err)
+	}
+
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = sr.Namespace
+	}
+
+	if namespace == "" {
+		return admission.Request{}, fmt.Errorf("namespace is required")
+	}
+
+	gvk := obj.GroupVersionKind()
+	raw := sr.Object.Raw
+
+	// round trip known kinds through their typed struct like the API server does, so the patch only contains the
+	// changes made by the handlers
+	if typed, err := clientgoscheme.Scheme.New(gvk); err == nil {
+		if err := json.Unmarshal(raw, typed); err != nil {
+			return admission.Request{}, fmt.Errorf("invalid object: %w", err)
+		}
+
+		if raw, err = json.Marshal(typed); err != nil {
+			return admission.Request{}, err
+		}
+	}
+
+	dryRun := true
+	// the handled kinds are all regular plurals
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       uuid.NewUUID(),
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Resource:  metav1.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource},
+		Namespace: namespace,
+		Name:      obj.GetName(),
+		Operation: admissionv1.Create,
+		UserInfo:  user,
+		Object:    runtime.RawExtension{Raw: raw},
+		DryRun:    &dryRun,
+	}}, nil
+}
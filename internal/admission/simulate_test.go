@@ -0,0 +1,170 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type MockAuthenticator struct{}
+
+func (m *MockAuthenticator) Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, error) {
+	if token != "valid" {
+		return authenticationv1.UserInfo{}, fmt.Errorf("invalid token")
+	}
+	return authenticationv1.UserInfo{Username: "developer"}, nil
+}
+
+type MockAuthorizer struct{}
+
+func (m *MockAuthorizer) Authorize(ctx context.Context, user authenticationv1.UserInfo, attributes authorizationv1.ResourceAttributes) error {
+	if user.Username != "developer" || attributes.Verb != "create" || attributes.Group != "apps" || attributes.Resource != "deployments" || attributes.Namespace != "test-ns" {
+		return fmt.Errorf("forbidden")
+	}
+	return nil
+}
+
+func TestSimulator(t *testing.T) {
+	t.Parallel()
+
+	decoder := admission.NewDecoder(runtime.NewScheme())
+	r, err := NewRouter(&MockLimitRanger{lrc: &limitrange.Config{}}, WithAdmissionHandlers(&MockDeploymentHandler{MockHandler{decoder: decoder}}))
+	assert.NoError(t, err)
+
+	s := NewSimulator(r, &MockAuthenticator{}, &MockAuthorizer{})
+
+	deployment := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"}}`
+
+	tests := []struct {
+		msg         string
+		method      string
+		token       string
+		body        string
+		wantStatus  int
+		wantAllowed bool
+		wantPatch   int
+	}{
+		{
+			msg:         "Simulate a mutation",
+			method:      http.MethodPost,
+			token:       "valid",
+			body:        fmt.Sprintf(`{"namespace":"test-ns","object":%s}`, deployment),
+			wantStatus:  http.StatusOK,
+			wantAllowed: true,
+			wantPatch:   1,
+		},
+		{
+			msg:        "Missing token",
+			method:     http.MethodPost,
+			body:       fmt.Sprintf(`{"namespace":"test-ns","object":%s}`, deployment),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			msg:        "Invalid token",
+			method:     http.MethodPost,
+			token:      "invalid",
+			body:       fmt.Sprintf(`{"namespace":"test-ns","object":%s}`, deployment),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			msg:        "Not allowed to create the object in the namespace",
+			method:     http.MethodPost,
+			token:      "valid",
+			body:       fmt.Sprintf(`{"namespace":"other-ns","object":%s}`, deployment),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			msg:        "Missing namespace",
+			method:     http.MethodPost,
+			token:      "valid",
+			body:       fmt.Sprintf(`{"object":%s}`, deployment),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			msg:        "Only POST is allowed",
+			method:     http.MethodGet,
+			token:      "valid",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/simulate", bytes.NewBufferString(test.body))
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, test.wantStatus, rec.Code, test.msg)
+
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		resp := SimulationResponse{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), test.msg)
+		assert.Equal(t, test.wantAllowed, resp.Allowed, test.msg)
+		assert.Len(t, resp.Patch, test.wantPatch, test.msg)
+	}
+}
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		review.Status.Authenticated = review.Spec.Token == "valid"
+		review.Status.User.Username = "developer"
+		return true, review, nil
+	})
+
+	a := NewTokenReviewAuthenticator(cs)
+
+	user, err := a.Authenticate(context.Background(), "valid")
+	assert.NoError(t, err)
+	assert.Equal(t, "developer", user.Username)
+
+	_, err = a.Authenticate(context.Background(), "invalid")
+	assert.Error(t, err)
+}
+
+func TestSubjectAccessReviewAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "developer" && slices.Contains(review.Spec.Groups, "team") &&
+			review.Spec.Extra["scopes"][0] == "simulate" && review.Spec.ResourceAttributes.Namespace == "test-ns"
+		return true, review, nil
+	})
+
+	a := NewSubjectAccessReviewAuthorizer(cs)
+	user := authenticationv1.UserInfo{
+		Username: "developer",
+		Groups:   []string{"team"},
+		Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"simulate"}},
+	}
+	attributes := authorizationv1.ResourceAttributes{Namespace: "test-ns", Verb: "create", Group: "apps", Resource: "deployments"}
+
+	assert.NoError(t, a.Authorize(context.Background(), user, attributes))
+
+	attributes.Namespace = "other-ns"
+	assert.Error(t, a.Authorize(context.Background(), user, attributes))
+}
@@ -0,0 +1,70 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	pkgadmission "github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// LimitRangeValidator denies LimitRanges with inconsistent memory limits and warns about LimitRanges whose defaults
+// would be denied by the mutator.
+type LimitRangeValidator struct {
+	decoder admission.Decoder
+	checker pkgadmission.ConfigChecker
+}
+
+func NewLimitRangeValidator(decoder admission.Decoder, checker pkgadmission.ConfigChecker) *LimitRangeValidator {
+	return &LimitRangeValidator{decoder: decoder, checker: checker}
+}
+
+func (v *LimitRangeValidator) SetupWithManager(m manager.Manager) {
+	m.GetWebhookServer().Register("/validate-limitrange", &webhook.Admission{Handler: v})
+}
+
+func (v *LimitRangeValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	lr := &corev1.LimitRange{}
+	if err := v.decoder.Decode(req, lr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if mode, ok := lr.Annotations[limitrange.EnforcementModeAnnotation]; ok {
+		if _, err := limitrange.ParseEnforcementMode(mode); err != nil {
+			return admission.Denied(fmt.Sprintf("limitrange %s: %s", lr.Name, err))
+		}
+	}
+
+	var warnings []string
+	for i, item := range lr.Spec.Limits {
+		if item.Type != corev1.LimitTypeContainer {
+			continue
+		}
+
+		cfg := limitrange.NewConfig(item, corev1.ResourceMemory)
+		if err := cfg.Validate(); err != nil {
+			return admission.Denied(fmt.Sprintf("limitrange %s: spec.limits[%d]: memory %s", lr.Name, i, err))
+		}
+
+		for _, err := range v.checker.CheckConfig(ctx, &cfg) {
+			warnings = append(warnings, fmt.Sprintf("limitrange %s: spec.limits[%d]: defaulted containers would be denied: %s", lr.Name, i, err))
+		}
+	}
+
+	if len(warnings) > 0 {
+		log.FromContext(ctx).Info("limitrange defaults would be denied", "namespace", req.Namespace, "name", lr.Name, "warnings", warnings)
+	}
+
+	return admission.Allowed("").WithWarnings(warnings...)
+}
@@ -0,0 +1,98 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type MockConfigChecker struct {
+	errs []error
+}
+
+func (m *MockConfigChecker) CheckConfig(ctx context.Context, cfg *limitrange.Config) []error {
+	return m.errs
+}
+
+func TestLimitRangeValidator(t *testing.T) {
+	t.Parallel()
+
+	decoder := admission.NewDecoder(clientgoscheme.Scheme)
+
+	limitRange := func(annotations map[string]string, items ...corev1.LimitRangeItem) []byte {
+		b, err := json.Marshal(&corev1.LimitRange{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "LimitRange"},
+			ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "test-ns", Annotations: annotations},
+			Spec:       corev1.LimitRangeSpec{Limits: items},
+		})
+		assert.NoError(t, err)
+		return b
+	}
+
+	memory := func(q string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(q)}
+	}
+
+	tests := []struct {
+		msg          string
+		object       []byte
+		checkErrs    []error
+		wantAllowed  bool
+		wantWarnings int
+	}{
+		{
+			msg:         "Consistent LimitRange",
+			object:      limitRange(nil, corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, Default: memory("128Mi"), DefaultRequest: memory("64Mi")}),
+			wantAllowed: true,
+		},
+		{
+			msg:    "Default request exceeds default limit",
+			object: limitRange(nil, corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, Default: memory("64Mi"), DefaultRequest: memory("128Mi")}),
+		},
+		{
+			msg:    "Ratio below 1",
+			object: limitRange(nil, corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, MaxLimitRequestRatio: memory("0.5")}),
+		},
+		{
+			msg:         "Pod limits are not checked",
+			object:      limitRange(nil, corev1.LimitRangeItem{Type: corev1.LimitTypePod, Default: memory("64Mi"), DefaultRequest: memory("128Mi")}),
+			wantAllowed: true,
+		},
+		{
+			msg:    "Invalid enforcement mode",
+			object: limitRange(map[string]string{limitrange.EnforcementModeAnnotation: "invalid"}),
+		},
+		{
+			msg:          "Defaults denied by the mutator",
+			object:       limitRange(nil, corev1.LimitRangeItem{Type: corev1.LimitTypeContainer, DefaultRequest: memory("64Mi")}),
+			checkErrs:    []error{errors.New("denied")},
+			wantAllowed:  true,
+			wantWarnings: 1,
+		},
+	}
+
+	for _, test := range tests {
+		v := NewLimitRangeValidator(decoder, &MockConfigChecker{errs: test.checkErrs})
+		resp := v.Handle(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Namespace: "test-ns",
+				Object:    runtime.RawExtension{Raw: test.object},
+			},
+		})
+
+		assert.Equal(t, test.wantAllowed, resp.Allowed, test.msg)
+		assert.Len(t, resp.Warnings, test.wantWarnings, test.msg)
+	}
+}
@@ -0,0 +1,255 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type Decision string
+
+const (
+	DecisionCompliant Decision = "compliant"
+	DecisionMutate    Decision = "mutate"
+	DecisionDeny      Decision = "deny"
+)
+
+// Lister returns the existing objects of a kind. Objects must have their TypeMeta populated so they can be routed to
+// the matching admission handler.
+type Lister interface {
+	List(ctx context.Context) ([]runtime.Object, error)
+}
+
+type ListerFunc func(ctx context.Context) ([]runtime.Object, error)
+
+func (f ListerFunc) List(ctx context.Context) ([]runtime.Object, error) {
+	return f(ctx)
+}
+
+type Result struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace"`
+	Name       string   `json:"name"`
+	Decision   Decision `json:"decision"`
+	Message    string   `json:"message,omitempty"`
+}
+
+type Report struct {
+	StartTime      time.Time `json:"startTime"`
+	CompletionTime time.Time `json:"completionTime"`
+	Results        []Result  `json:"results"`
+	Errors         []string  `json:"errors,omitempty"`
+}
+
+// ReportHandler receives every completed audit report.
+type ReportHandler interface {
+	HandleReport(ctx context.Context, report Report) error
+}
+
+type OptionsFunc func(*Auditor)
+
+func WithReportHandlers(handlers ...ReportHandler) OptionsFunc {
+	return func(a *Auditor) {
+		a.reportHandlers = append(a.reportHandlers, handlers...)
+	}
+}
+
+func WithInterval(interval time.Duration) OptionsFunc {
+	return func(a *Auditor) {
+		a.interval = interval
+	}
+}
+
+// WithCacheSyncs delays the first audit until the informers backing the admission handler are synced.
+func WithCacheSyncs(hasSynced ...cache.InformerSynced) OptionsFunc {
+	return func(a *Auditor) {
+		a.hasSynced = append(a.hasSynced, hasSynced...)
+	}
+}
+
+// Auditor periodically evaluates existing workloads against the admission handler without persisting any change, so
+// workloads admitted before hedgetrimmer was installed are reported.
+type Auditor struct {
+	handler        admission.Handler
+	listers        []Lister
+	interval       time.Duration
+	reportHandlers []ReportHandler
+	hasSynced      []cache.InformerSynced
+
+	mu     sync.RWMutex
+	report Report
+}
+
+func NewAuditor(handler admission.Handler, listers []Lister, opts ...OptionsFunc) *Auditor {
+	a := &Auditor{
+		handler:  handler,
+		listers:  listers,
+		interval: 1 * time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// SetupWithManager runs the auditor on the elected leader and serves the latest report on the metrics server.
+func (a *Auditor) SetupWithManager(m manager.Manager) error {
+	if err := m.Add(a); err != nil {
+		return err
+	}
+
+	return m.AddMetricsServerExtraHandler("/audit", a)
+}
+
+func (a *Auditor) NeedLeaderElection() bool {
+	return true
+}
+
+func (a *Auditor) Start(ctx context.Context) error {
+	// an unsynced cache makes namespaces look unconfigured
+	if !cache.WaitForCacheSync(ctx.Done(), a.hasSynced...) {
+		return nil
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		a.Audit(ctx)
+	}, a.interval)
+
+	return nil
+}
+
+// Audit evaluates every listed object and stores the resulting report.
+func (a *Auditor) Audit(ctx context.Context) Report {
+	logr := log.FromContext(ctx).WithName("audit")
+
+	report := Report{StartTime: time.Now()}
+	for _, lister := range a.listers {
+		objects, err := lister.List(ctx)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			continue
+		}
+
+		for _, obj := range objects {
+			result, err := a.Evaluate(ctx, obj)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				continue
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	report.CompletionTime = time.Now()
+
+	recordMetrics(report)
+	logr.Info("audit complete", summary(report.Results)...)
+
+	for _, h := range a.reportHandlers {
+		if err := h.HandleReport(ctx, report); err != nil {
+			logr.Error(err, "failed to handle audit report")
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.report = report
+
+	return report
+}
+
+// Evaluate runs a single object through the admission handler as a dry-run CREATE request.
+func (a *Auditor) Evaluate(ctx context.Context, obj runtime.Object) (Result, error) {
+	result, _, err := a.evaluate(ctx, obj)
+	return result, err
+}
+
+// evaluate returns the Result and the admission response of a dry-run CREATE request.
+func (a *Auditor) evaluate(ctx context.Context, obj runtime.Object) (Result, admission.Response, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return Result{}, admission.Response{}, err
+	}
+
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	result := Result{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  accessor.GetNamespace(),
+		Name:       accessor.GetName(),
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return result, admission.Response{}, fmt.Errorf("failed to encode %s %s/%s: %w", result.Kind, result.Namespace, result.Name, err)
+	}
+
+	dryRun := true
+	resp := a.handler.Handle(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       types.UID(fmt.Sprintf("audit-%s", accessor.GetUID())),
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Namespace: result.Namespace,
+		Name:      result.Name,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+		DryRun:    &dryRun,
+	}})
+
+	switch {
+	case !resp.Allowed:
+		result.Decision = DecisionDeny
+		if resp.Result != nil {
+			result.Message = resp.Result.Message
+		}
+	case len(resp.Patches) > 0:
+		result.Decision = DecisionMutate
+		result.Message = fmt.Sprintf("%d fields would be patched", len(resp.Patches))
+	default:
+		result.Decision = DecisionCompliant
+	}
+
+	return result, resp, nil
+}
+
+// Report returns the most recent audit report.
+func (a *Auditor) Report() Report {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.report
+}
+
+func (a *Auditor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.Report()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func summary(results []Result) []interface{} {
+	counts := map[Decision]int{}
+	for _, r := range results {
+		counts[r.Decision]++
+	}
+
+	return []interface{}{
+		string(DecisionCompliant), counts[DecisionCompliant],
+		string(DecisionMutate), counts[DecisionMutate],
+		string(DecisionDeny), counts[DecisionDeny],
+	}
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type MockHandler struct {
+	responses map[string]admission.Response
+}
+
+func (m *MockHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	return m.responses[req.Name]
+}
+
+func deployment(name string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+	}
+}
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	handler := &MockHandler{responses: map[string]admission.Response{
+		"compliant": admission.Allowed(""),
+		"mutate":    admission.PatchResponseFromRaw([]byte(`{}`), []byte(`{"a":"b"}`)),
+		"deny":      admission.Denied("memory limit exceeds ratio"),
+	}}
+
+	listers := []Lister{
+		ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+			return []runtime.Object{deployment("compliant"), deployment("mutate"), deployment("deny")}, nil
+		}),
+		ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+			return nil, fmt.Errorf("forbidden")
+		}),
+	}
+
+	a := NewAuditor(handler, listers)
+	report := a.Audit(context.Background())
+
+	assert.Equal(t, []Result{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "test-ns", Name: "compliant", Decision: DecisionCompliant},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "test-ns", Name: "mutate", Decision: DecisionMutate, Message: "1 fields would be patched"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "test-ns", Name: "deny", Decision: DecisionDeny, Message: "memory limit exceeds ratio"},
+	}, report.Results)
+	assert.Equal(t, []string{"forbidden"}, report.Errors)
+	assert.Equal(t, report, a.Report())
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("GET", "/audit", nil))
+
+	served := Report{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, report.Results, served.Results)
+}
+
+func TestAuditWaitsForCacheSync(t *testing.T) {
+	t.Parallel()
+
+	var synced atomic.Bool
+	audited := make(chan struct{}, 1)
+	lister := ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+		assert.True(t, synced.Load(), "audit before the cache is synced")
+		select {
+		case audited <- struct{}{}:
+		default:
+		}
+		return nil, nil
+	})
+
+	a := NewAuditor(&MockHandler{}, []Lister{lister}, WithInterval(time.Hour), WithCacheSyncs(synced.Load))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- a.Start(ctx) }()
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Empty(t, audited)
+
+	synced.Store(true)
+	select {
+	case <-audited:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no audit after the cache synced")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,333 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	ReasonLimitRangeImpact = "LimitRangeImpact"
+
+	// maxImpactedInEvent bounds the workloads named in an Event, the API server truncates long messages.
+	maxImpactedInEvent = 10
+)
+
+// HandlerFactory returns an admission handler evaluating objects against the given Config, which is nil for
+// namespaces without a Container LimitRange.
+type HandlerFactory func(cfg *limitrange.Config) (admission.Handler, error)
+
+// NamespaceListers returns the listers of the workloads in a namespace.
+type NamespaceListers func(namespace string) ([]Lister, error)
+
+// Impact lists the workloads which would be denied or mutated differently after a LimitRange update.
+type Impact struct {
+	Denied  []Result
+	Mutated []Result
+}
+
+func (i Impact) empty() bool {
+	return len(i.Denied) == 0 && len(i.Mutated) == 0
+}
+
+type ImpactOptionsFunc func(*ImpactAnalyzer)
+
+// WithDelay sets how long updates to a LimitRange are collected before they are analyzed.
+func WithDelay(d time.Duration) ImpactOptionsFunc {
+	return func(a *ImpactAnalyzer) {
+		a.delay = d
+	}
+}
+
+// ImpactAnalyzer evaluates the workloads of a namespace against the previous and the updated LimitRanges whenever a
+// LimitRange changes, and reports the workloads which would now be denied or re-defaulted.
+type ImpactAnalyzer struct {
+	lister     corev1Listers.LimitRangeLister
+	newHandler HandlerFactory
+	listers    NamespaceListers
+	recorder   record.EventRecorder
+	delay      time.Duration
+
+	queue workqueue.TypedDelayingInterface[string]
+	// elected is set once the analyzer is started on the leader, other replicas do not queue updates
+	elected atomic.Bool
+
+	mu       sync.Mutex
+	previous map[string]*corev1.LimitRange
+}
+
+func NewImpactAnalyzer(lister corev1Listers.LimitRangeLister, newHandler HandlerFactory, listers NamespaceListers, recorder record.EventRecorder, opts ...ImpactOptionsFunc) *ImpactAnalyzer {
+	a := &ImpactAnalyzer{
+		lister:     lister,
+		newHandler: newHandler,
+		listers:    listers,
+		recorder:   recorder,
+		delay:      10 * time.Second,
+		queue:      workqueue.NewTypedDelayingQueue[string](),
+		previous:   map[string]*corev1.LimitRange{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+func (a *ImpactAnalyzer) SetupWithManager(m manager.Manager) error {
+	return m.Add(a)
+}
+
+// NeedLeaderElection is true so a single replica publishes Events.
+func (a *ImpactAnalyzer) NeedLeaderElection() bool {
+	return true
+}
+
+// ResourceEventHandler queues updated and deleted LimitRanges, it must be registered with the informer backing the
+// lister.
+func (a *ImpactAnalyzer) ResourceEventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if !a.elected.Load() {
+				return
+			}
+
+			oldLR, okOld := oldObj.(*corev1.LimitRange)
+			newLR, okNew := newObj.(*corev1.LimitRange)
+			if !okOld || !okNew || !changed(oldLR, newLR) {
+				return
+			}
+
+			key, err := cache.MetaNamespaceKeyFunc(newLR)
+			if err != nil {
+				return
+			}
+
+			a.mu.Lock()
+			// the oldest version is kept so updates collected during the delay are analyzed together
+			if _, ok := a.previous[key]; !ok {
+				a.previous[key] = oldLR
+			}
+			a.mu.Unlock()
+
+			a.queue.AddAfter(key, a.delay)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if !a.elected.Load() {
+				return
+			}
+
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+
+			a.mu.Lock()
+			delete(a.previous, key)
+			a.mu.Unlock()
+
+			// the metrics of the deleted LimitRange are removed when the key is processed
+			a.queue.Add(key)
+		},
+	}
+}
+
+// changed ignores updates which do not affect the compiled Config, e.g. resyncs or label changes.
+func changed(oldLR, newLR *corev1.LimitRange) bool {
+	return !equality.Semantic.DeepEqual(oldLR.Spec, newLR.Spec) ||
+		oldLR.Annotations[limitrange.EnforcementModeAnnotation] != newLR.Annotations[limitrange.EnforcementModeAnnotation]
+}
+
+func (a *ImpactAnalyzer) Start(ctx context.Context) error {
+	a.elected.Store(true)
+
+	go func() {
+		<-ctx.Done()
+		a.queue.ShutDown()
+	}()
+
+	for a.processNext(ctx) {
+	}
+
+	return nil
+}
+
+func (a *ImpactAnalyzer) processNext(ctx context.Context) bool {
+	key, shutdown := a.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer a.queue.Done(key)
+
+	a.mu.Lock()
+	previous := a.previous[key]
+	delete(a.previous, key)
+	a.mu.Unlock()
+
+	logr := log.FromContext(ctx).WithName("impact").WithValues("limitrange", key)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return true
+	}
+
+	current, err := a.lister.LimitRanges(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		forgetImpact(namespace, name)
+		return true
+	}
+
+	if err != nil || previous == nil {
+		return true
+	}
+
+	impact, err := a.Analyze(ctx, previous, current)
+	if err != nil {
+		logr.Error(err, "failed to analyze limitrange impact")
+		return true
+	}
+
+	recordImpact(namespace, name, impact)
+	logr.Info("limitrange impact analyzed", "denied", len(impact.Denied), "mutated", len(impact.Mutated))
+
+	if a.recorder != nil {
+		eventType := corev1.EventTypeNormal
+		if !impact.empty() {
+			eventType = corev1.EventTypeWarning
+		}
+		a.recorder.Event(current, eventType, ReasonLimitRangeImpact, impact.message())
+	}
+
+	return true
+}
+
+// Analyze evaluates the workloads in the namespace of current against the Configs of the namespace with previous and
+// with current.
+func (a *ImpactAnalyzer) Analyze(ctx context.Context, previous, current *corev1.LimitRange) (Impact, error) {
+	ranges, err := a.lister.LimitRanges(current.Namespace).List(labels.Everything())
+	if err != nil {
+		return Impact{}, err
+	}
+
+	before := make([]*corev1.LimitRange, 0, len(ranges))
+	after := make([]*corev1.LimitRange, 0, len(ranges))
+	for _, lr := range ranges {
+		after = append(after, lr)
+		if lr.Name == current.Name {
+			lr = previous
+		}
+		before = append(before, lr)
+	}
+
+	beforeCfg, err := limitrange.CompileConfig(before...)
+	if err != nil {
+		return Impact{}, err
+	}
+
+	afterCfg, err := limitrange.CompileConfig(after...)
+	if err != nil {
+		return Impact{}, err
+	}
+
+	if equality.Semantic.DeepEqual(beforeCfg, afterCfg) {
+		// the LimitRange is not the one in effect
+		return Impact{}, nil
+	}
+
+	beforeHandler, err := a.newHandler(beforeCfg)
+	if err != nil {
+		return Impact{}, err
+	}
+
+	afterHandler, err := a.newHandler(afterCfg)
+	if err != nil {
+		return Impact{}, err
+	}
+
+	listers, err := a.listers(current.Namespace)
+	if err != nil {
+		return Impact{}, err
+	}
+
+	beforeAuditor := NewAuditor(beforeHandler, nil)
+	afterAuditor := NewAuditor(afterHandler, nil)
+
+	impact := Impact{}
+	for _, lister := range listers {
+		objects, err := lister.List(ctx)
+		if err != nil {
+			return Impact{}, err
+		}
+
+		for _, obj := range objects {
+			result, resp, err := afterAuditor.evaluate(ctx, obj)
+			if err != nil {
+				return Impact{}, err
+			}
+
+			if result.Decision == DecisionCompliant {
+				continue
+			}
+
+			previousResult, previousResp, err := beforeAuditor.evaluate(ctx, obj)
+			if err != nil {
+				return Impact{}, err
+			}
+
+			// workloads already denied or mutated the same way are not affected by the change
+			if previousResult.Decision == result.Decision && previousResult.Message == result.Message &&
+				equality.Semantic.DeepEqual(previousResp.Patches, resp.Patches) {
+				continue
+			}
+
+			if result.Decision == DecisionDeny {
+				impact.Denied = append(impact.Denied, result)
+			} else {
+				impact.Mutated = append(impact.Mutated, result)
+			}
+		}
+	}
+
+	return impact, nil
+}
+
+func (i Impact) message() string {
+	if i.empty() {
+		return "No existing workloads are affected"
+	}
+
+	var names []string
+	for _, results := range []struct {
+		decision Decision
+		results  []Result
+	}{
+		{DecisionDeny, i.Denied},
+		{DecisionMutate, i.Mutated},
+	} {
+		for _, r := range results.results {
+			names = append(names, fmt.Sprintf("%s/%s (%s)", r.Kind, r.Name, results.decision))
+		}
+	}
+
+	if len(names) > maxImpactedInEvent {
+		names = append(names[:maxImpactedInEvent], fmt.Sprintf("and %d more", len(names)-maxImpactedInEvent))
+	}
+
+	return fmt.Sprintf("%d existing workloads would be denied and %d re-defaulted: %s", len(i.Denied), len(i.Mutated), strings.Join(names, ", "))
+}
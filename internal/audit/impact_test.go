@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func limitRange(name, max string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", ResourceVersion: max},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			Max:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(max)},
+		}}},
+	}
+}
+
+// maxHandlerFactory denies the "large" deployment once Max is below 1Gi and mutates the "small" deployment once Max
+// is below 512Mi.
+func maxHandlerFactory(cfg *limitrange.Config) (admission.Handler, error) {
+	responses := map[string]admission.Response{
+		"large": admission.Allowed(""),
+		"small": admission.Allowed(""),
+	}
+
+	if cfg != nil && cfg.Max.Cmp(resource.MustParse("1Gi")) == -1 {
+		responses["large"] = admission.Denied("memory limit exceeds Max")
+	}
+
+	if cfg != nil && cfg.Max.Cmp(resource.MustParse("512Mi")) == -1 {
+		responses["small"] = admission.PatchResponseFromRaw([]byte(`{}`), []byte(`{"limit":"`+cfg.Max.String()+`"}`))
+	}
+
+	return &MockHandler{responses: responses}, nil
+}
+
+func TestImpactAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	listers := func(namespace string) ([]Lister, error) {
+		return []Lister{ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+			return []runtime.Object{deployment("large"), deployment("small")}, nil
+		})}, nil
+	}
+
+	tests := []struct {
+		msg         string
+		previous    *corev1.LimitRange
+		current     *corev1.LimitRange
+		others      []*corev1.LimitRange
+		wantDenied  []string
+		wantMutated []string
+	}{
+		{
+			msg:        "Lowering Max denies a workload",
+			previous:   limitRange("limits", "2Gi"),
+			current:    limitRange("limits", "768Mi"),
+			wantDenied: []string{"large"},
+		},
+		{
+			msg:         "Lowering Max further re-defaults a workload",
+			previous:    limitRange("limits", "768Mi"),
+			current:     limitRange("limits", "256Mi"),
+			wantMutated: []string{"small"},
+		},
+		{
+			msg:         "Changing the computed values re-defaults a workload",
+			previous:    limitRange("limits", "256Mi"),
+			current:     limitRange("limits", "128Mi"),
+			wantMutated: []string{"small"},
+		},
+		{
+			msg:      "Raising Max affects no workload",
+			previous: limitRange("limits", "768Mi"),
+			current:  limitRange("limits", "2Gi"),
+		},
+		{
+			msg:      "A LimitRange which is not in effect affects no workload",
+			previous: limitRange("limits", "2Gi"),
+			current:  limitRange("limits", "256Mi"),
+			others:   []*corev1.LimitRange{limitRange("a-limits", "2Gi")},
+		},
+	}
+
+	for _, test := range tests {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		for _, lr := range append(test.others, test.current) {
+			assert.NoError(t, indexer.Add(lr), test.msg)
+		}
+
+		a := NewImpactAnalyzer(corev1Listers.NewLimitRangeLister(indexer), maxHandlerFactory, listers, nil)
+		impact, err := a.Analyze(context.Background(), test.previous, test.current)
+		assert.NoError(t, err, test.msg)
+
+		var denied, mutated []string
+		for _, r := range impact.Denied {
+			denied = append(denied, r.Name)
+		}
+		for _, r := range impact.Mutated {
+			mutated = append(mutated, r.Name)
+		}
+
+		assert.Equal(t, test.wantDenied, denied, test.msg)
+		assert.Equal(t, test.wantMutated, mutated, test.msg)
+	}
+}
+
+func TestImpactAnalyzerPublishesEvent(t *testing.T) {
+	t.Parallel()
+
+	previous := limitRange("limits", "2Gi")
+	current := limitRange("limits", "256Mi")
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, indexer.Add(current))
+
+	listers := func(namespace string) ([]Lister, error) {
+		return []Lister{ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+			return []runtime.Object{deployment("large"), deployment("small")}, nil
+		})}, nil
+	}
+
+	recorder := record.NewFakeRecorder(1)
+	a := NewImpactAnalyzer(corev1Listers.NewLimitRangeLister(indexer), maxHandlerFactory, listers, recorder, WithDelay(0))
+
+	handler := a.ResourceEventHandler()
+	// updates are only queued on the leader
+	handler.OnUpdate(previous, current)
+	assert.Equal(t, 0, a.queue.Len())
+
+	a.elected.Store(true)
+	// resyncs are ignored
+	handler.OnUpdate(current, current)
+	assert.Equal(t, 0, a.queue.Len())
+
+	handler.OnUpdate(previous, current)
+	assert.True(t, a.processNext(context.Background()))
+
+	event := <-recorder.Events
+	assert.True(t, strings.HasPrefix(event, "Warning LimitRangeImpact 1 existing workloads would be denied and 1 re-defaulted"), event)
+	assert.Contains(t, event, "Deployment/large (deny)")
+	assert.Contains(t, event, "Deployment/small (mutate)")
+}
+
+func TestImpactAnalyzerForgetsDeletedLimitRange(t *testing.T) {
+	t.Parallel()
+
+	previous := limitRange("deleted", "2Gi")
+	previous.Namespace = "deleted-ns"
+	current := limitRange("deleted", "256Mi")
+	current.Namespace = "deleted-ns"
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, indexer.Add(current))
+
+	listers := func(namespace string) ([]Lister, error) {
+		return []Lister{ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+			return []runtime.Object{deployment("large")}, nil
+		})}, nil
+	}
+
+	a := NewImpactAnalyzer(corev1Listers.NewLimitRangeLister(indexer), maxHandlerFactory, listers, nil, WithDelay(0))
+	a.elected.Store(true)
+
+	handler := a.ResourceEventHandler()
+	handler.OnUpdate(previous, current)
+	assert.True(t, a.processNext(context.Background()))
+	assert.Equal(t, float64(1), testutil.ToFloat64(impactedWorkloads.WithLabelValues("deleted-ns", "deleted", string(DecisionDeny))))
+
+	assert.NoError(t, indexer.Delete(current))
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "deleted-ns/deleted", Obj: current})
+	assert.True(t, a.processNext(context.Background()))
+
+	for _, decision := range []Decision{DecisionDeny, DecisionMutate} {
+		assert.False(t, impactedWorkloads.DeleteLabelValues("deleted-ns", "deleted", string(decision)), decision)
+	}
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	workloads = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hedgetrimmer_audit_workloads",
+		Help: "Number of existing workloads by audit decision",
+	}, []string{"kind", "namespace", "decision"})
+
+	lastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hedgetrimmer_audit_last_run_timestamp_seconds",
+		Help: "Completion time of the last audit run",
+	})
+
+	listErrors = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hedgetrimmer_audit_errors",
+		Help: "Number of errors encountered during the last audit run",
+	})
+
+	impactedWorkloads = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hedgetrimmer_limitrange_impacted_workloads",
+		Help: "Number of existing workloads which would be denied or re-defaulted after the last update of a LimitRange",
+	}, []string{"namespace", "limitrange", "decision"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(workloads, lastRun, listErrors, impactedWorkloads)
+}
+
+func recordMetrics(report Report) {
+	workloads.Reset()
+	for _, r := range report.Results {
+		workloads.WithLabelValues(r.Kind, r.Namespace, string(r.Decision)).Inc()
+	}
+
+	listErrors.Set(float64(len(report.Errors)))
+	lastRun.Set(float64(report.CompletionTime.Unix()))
+}
+
+func recordImpact(namespace, name string, impact Impact) {
+	impactedWorkloads.WithLabelValues(namespace, name, string(DecisionDeny)).Set(float64(len(impact.Denied)))
+	impactedWorkloads.WithLabelValues(namespace, name, string(DecisionMutate)).Set(float64(len(impact.Mutated)))
+}
+
+func forgetImpact(namespace, name string) {
+	impactedWorkloads.DeleteLabelValues(namespace, name, string(DecisionDeny))
+	impactedWorkloads.DeleteLabelValues(namespace, name, string(DecisionMutate))
+}
@@ -0,0 +1,187 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// NamespaceSelectorAnnotation selects the namespaces a LimitRange template applies to, templates without it
+	// apply to every namespace.
+	NamespaceSelectorAnnotation = "hedgetrimmer.kanopy-platform.github.io/namespace-selector"
+	// TemplateLabel is set on LimitRanges created from a template.
+	TemplateLabel = "hedgetrimmer.kanopy-platform.github.io/template"
+
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	managedBy      = "hedgetrimmer"
+)
+
+// Template is a LimitRange created in the namespaces matching its selector.
+type Template struct {
+	Selector   labels.Selector
+	LimitRange *corev1.LimitRange
+}
+
+// NewTemplates returns the templates ordered by name, the selector is read from the NamespaceSelectorAnnotation.
+func NewTemplates(ranges ...*corev1.LimitRange) ([]Template, error) {
+	var templates []Template
+	for _, lr := range ranges {
+		selector := labels.Everything()
+		if s, ok := lr.Annotations[NamespaceSelectorAnnotation]; ok {
+			var err error
+			if selector, err = labels.Parse(s); err != nil {
+				return nil, fmt.Errorf("limitrange template %s: invalid namespace selector: %w", lr.Name, err)
+			}
+		}
+
+		templates = append(templates, Template{Selector: selector, LimitRange: lr})
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool {
+		return templates[i].LimitRange.Name < templates[j].LimitRange.Name
+	})
+
+	return templates, nil
+}
+
+// Reconciler creates a LimitRange from the first matching template in namespaces without a LimitRange. LimitRanges
+// it created are kept in sync with their template, any other LimitRange is never modified.
+type Reconciler struct {
+	client    client.Client
+	templates []Template
+}
+
+func NewReconciler(c client.Client, templates []Template) *Reconciler {
+	return &Reconciler{client: c, templates: templates}
+}
+
+func (r *Reconciler) SetupWithManager(m ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(m).
+		Named("limitrange-bootstrap").
+		For(&corev1.Namespace{}).
+		Watches(&corev1.LimitRange{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: o.GetNamespace()}}}
+		})).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := log.FromContext(ctx)
+
+	ns := &corev1.Namespace{}
+	if err := r.client.Get(ctx, req.NamespacedName, ns); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if ns.Status.Phase == corev1.NamespaceTerminating || !ns.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	ranges := &corev1.LimitRangeList{}
+	if err := r.client.List(ctx, ranges, client.InNamespace(ns.Name)); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	template := r.templateFor(ns)
+
+	var current *corev1.LimitRange
+	unmanaged := false
+	for i := range ranges.Items {
+		lr := &ranges.Items[i]
+
+		switch {
+		case lr.Labels[ManagedByLabel] != managedBy:
+			unmanaged = true
+		case template != nil && lr.Name == template.LimitRange.Name:
+			current = lr
+		default:
+			// created from a template which no longer applies
+			log.Info("deleting limitrange from stale template", "namespace", ns.Name, "name", lr.Name)
+			if err := r.client.Delete(ctx, lr); client.IgnoreNotFound(err) != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	if template == nil {
+		return reconcile.Result{}, nil
+	}
+
+	desired := r.limitRangeFor(ns.Name, template)
+
+	if current == nil {
+		if unmanaged {
+			return reconcile.Result{}, nil
+		}
+
+		log.Info("creating limitrange from template", "namespace", ns.Name, "name", desired.Name)
+		if err := r.client.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if equality.Semantic.DeepEqual(current.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(current.Labels, desired.Labels) &&
+		equality.Semantic.DeepEqual(current.Annotations, desired.Annotations) {
+		return reconcile.Result{}, nil
+	}
+
+	current.Spec = desired.Spec
+	current.Labels = desired.Labels
+	current.Annotations = desired.Annotations
+
+	log.Info("updating limitrange from template", "namespace", ns.Name, "name", current.Name)
+	return reconcile.Result{}, r.client.Update(ctx, current)
+}
+
+func (r *Reconciler) templateFor(ns *corev1.Namespace) *Template {
+	for i, t := range r.templates {
+		if t.Selector.Matches(labels.Set(ns.Labels)) {
+			return &r.templates[i]
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) limitRangeFor(namespace string, t *Template) *corev1.LimitRange {
+	lr := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      t.LimitRange.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				ManagedByLabel: managedBy,
+				TemplateLabel:  t.LimitRange.Name,
+			},
+		},
+		Spec: *t.LimitRange.Spec.DeepCopy(),
+	}
+
+	for k, v := range t.LimitRange.Labels {
+		lr.Labels[k] = v
+	}
+
+	for k, v := range t.LimitRange.Annotations {
+		if k == NamespaceSelectorAnnotation {
+			continue
+		}
+		if lr.Annotations == nil {
+			lr.Annotations = map[string]string{}
+		}
+		lr.Annotations[k] = v
+	}
+
+	return lr
+}
@@ -0,0 +1,151 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func template(name, selector, memory string) *corev1.LimitRange {
+	lr := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:           corev1.LimitTypeContainer,
+					DefaultRequest: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+				},
+			},
+		},
+	}
+	if selector != "" {
+		lr.Annotations = map[string]string{NamespaceSelectorAnnotation: selector}
+	}
+	return lr
+}
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func managed(lr *corev1.LimitRange, namespace string) *corev1.LimitRange {
+	lr = lr.DeepCopy()
+	lr.Namespace = namespace
+	lr.Annotations = nil
+	lr.Labels = map[string]string{ManagedByLabel: managedBy, TemplateLabel: lr.Name}
+	return lr
+}
+
+func TestNewTemplates(t *testing.T) {
+	t.Parallel()
+
+	templates, err := NewTemplates(template("b", "", "1Gi"), template("a", "tier=prod", "2Gi"))
+	assert.NoError(t, err)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, "a", templates[0].LimitRange.Name)
+	assert.Equal(t, "tier=prod", templates[0].Selector.String())
+	assert.True(t, templates[1].Selector.Empty())
+
+	_, err = NewTemplates(template("c", "tier in (", "1Gi"))
+	assert.Error(t, err)
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(scheme))
+
+	prod := template("prod", "tier=prod", "2Gi")
+	dev := template("dev", "tier=dev", "1Gi")
+	templates, err := NewTemplates(prod, dev)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		msg       string
+		namespace *corev1.Namespace
+		existing  []client.Object
+		want      []*corev1.LimitRange
+	}{
+		{
+			msg:       "Create from the template matching the namespace labels",
+			namespace: namespace("a", map[string]string{"tier": "prod"}),
+			want:      []*corev1.LimitRange{managed(prod, "a")},
+		},
+		{
+			msg:       "No matching template",
+			namespace: namespace("a", map[string]string{"tier": "test"}),
+		},
+		{
+			msg:       "Existing LimitRanges are kept",
+			namespace: namespace("a", map[string]string{"tier": "dev"}),
+			existing:  []client.Object{&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: "a"}}},
+			want:      []*corev1.LimitRange{{ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: "a"}}},
+		},
+		{
+			msg:       "Unmanaged LimitRange with the template name is kept",
+			namespace: namespace("a", map[string]string{"tier": "dev"}),
+			existing:  []client.Object{&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "a"}}},
+			want:      []*corev1.LimitRange{{ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "a"}}},
+		},
+		{
+			msg:       "Managed LimitRange is updated from its template",
+			namespace: namespace("a", map[string]string{"tier": "dev"}),
+			existing:  []client.Object{managed(template("dev", "", "512Mi"), "a")},
+			want:      []*corev1.LimitRange{managed(dev, "a")},
+		},
+		{
+			msg:       "Managed LimitRange from another template is replaced",
+			namespace: namespace("a", map[string]string{"tier": "prod"}),
+			existing:  []client.Object{managed(dev, "a")},
+			want:      []*corev1.LimitRange{managed(prod, "a")},
+		},
+	}
+
+	for _, test := range tests {
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(append(test.existing, test.namespace)...).
+			Build()
+		ctx := context.Background()
+
+		_, err := NewReconciler(c, templates).Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: test.namespace.Name}})
+		assert.NoError(t, err, test.msg)
+
+		ranges := &corev1.LimitRangeList{}
+		assert.NoError(t, c.List(ctx, ranges, client.InNamespace(test.namespace.Name)), test.msg)
+		assert.Len(t, ranges.Items, len(test.want), test.msg)
+
+		for i, want := range test.want {
+			if i >= len(ranges.Items) {
+				break
+			}
+			got := ranges.Items[i]
+			assert.Equal(t, want.Name, got.Name, test.msg)
+			assert.Equal(t, want.Labels, got.Labels, test.msg)
+			assert.Equal(t, want.Spec, got.Spec, test.msg)
+		}
+	}
+}
+
+func TestReconcileMissingNamespace(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(scheme))
+
+	templates, err := NewTemplates(template("default", "", "1Gi"))
+	assert.NoError(t, err)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	_, err = NewReconciler(c, templates).Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "gone"}})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,349 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	CACertKey = "ca.crt"
+	CAKeyKey  = "ca.key"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedBy      = "hedgetrimmer"
+)
+
+// DNSNames returns the DNS names of a Service inside the cluster.
+func DNSNames(namespace, service string) []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", service, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace),
+	}
+}
+
+type OptionsFunc func(*Rotator)
+
+func WithCAValidity(d time.Duration) OptionsFunc {
+	return func(r *Rotator) {
+		r.caValidity = d
+	}
+}
+
+func WithCertValidity(d time.Duration) OptionsFunc {
+	return func(r *Rotator) {
+		r.certValidity = d
+	}
+}
+
+// WithRenewBefore sets how long before expiry the CA and the serving certificate are replaced.
+func WithRenewBefore(d time.Duration) OptionsFunc {
+	return func(r *Rotator) {
+		r.renewBefore = d
+	}
+}
+
+func WithCheckInterval(d time.Duration) OptionsFunc {
+	return func(r *Rotator) {
+		r.checkInterval = d
+	}
+}
+
+// WithOnRotate registers a function called whenever the CA bundle changes.
+func WithOnRotate(fn func()) OptionsFunc {
+	return func(r *Rotator) {
+		r.onRotate = fn
+	}
+}
+
+// Rotator keeps a self-signed CA and a serving certificate in a Secret and writes the serving certificate into the
+// webhook certificate directory, where the webhook server picks up changes.
+//
+// The Secret holds every CA which is still trusted. A new CA is added to the bundle before it expires but only signs
+// serving certificates once the bundle had time to propagate to the webhook configuration, so requests never reach a
+// replica serving a certificate the API server does not trust yet.
+type Rotator struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	certDir   string
+	dnsNames  []string
+
+	caValidity       time.Duration
+	certValidity     time.Duration
+	renewBefore      time.Duration
+	propagationDelay time.Duration
+	checkInterval    time.Duration
+	onRotate         func()
+	now              func() time.Time
+
+	mu       sync.RWMutex
+	caBundle []byte
+	notAfter time.Time
+}
+
+func NewRotator(client kubernetes.Interface, namespace, name, certDir string, dnsNames []string, opts ...OptionsFunc) *Rotator {
+	r := &Rotator{
+		client:           client,
+		namespace:        namespace,
+		name:             name,
+		certDir:          certDir,
+		dnsNames:         dnsNames,
+		caValidity:       5 * 365 * 24 * time.Hour,
+		certValidity:     365 * 24 * time.Hour,
+		renewBefore:      30 * 24 * time.Hour,
+		propagationDelay: 5 * time.Minute,
+		checkInterval:    time.Minute,
+		onRotate:         func() {},
+		now:              time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *Rotator) SetupWithManager(m manager.Manager) error {
+	return m.Add(r)
+}
+
+// NeedLeaderElection is false, every replica needs the serving certificate on disk.
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Rotator) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Ensure(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed to ensure webhook certificates")
+		}
+	}, r.checkInterval)
+
+	return nil
+}
+
+// CABundle returns the trusted CAs of the last loaded Secret.
+func (r *Rotator) CABundle(ctx context.Context) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.caBundle, nil
+}
+
+// NotAfter returns the expiry of the serving certificate on disk, zero before the first Ensure.
+func (r *Rotator) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.notAfter
+}
+
+// Ensure creates or rotates the certificates in the Secret and writes the serving certificate to disk. It must run
+// once before the webhook server starts.
+func (r *Rotator) Ensure(ctx context.Context) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		// another replica created or rotated the Secret first
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		return r.ensure(ctx)
+	})
+}
+
+func (r *Rotator) ensure(ctx context.Context) error {
+	secrets := r.client.CoreV1().Secrets(r.namespace)
+
+	secret, err := secrets.Get(ctx, r.name, metav1.GetOptions{})
+	notFound := apierrors.IsNotFound(err)
+	if err != nil && !notFound {
+		return err
+	}
+
+	if notFound {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.name,
+				Namespace: r.namespace,
+				Labels:    map[string]string{managedByLabel: managedBy},
+			},
+			Type: corev1.SecretTypeTLS,
+		}
+	}
+
+	data, changed, err := r.rotate(secret.Data, r.now())
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		secret.Data = data
+
+		if notFound {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		} else {
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+
+		log.FromContext(ctx).Info("rotated webhook certificates", "secret", r.namespace+"/"+r.name)
+	}
+
+	return r.load(data)
+}
+
+// rotate returns the Secret data with certificates which are valid at the given time and whether it changed.
+func (r *Rotator) rotate(data map[string][]byte, now time.Time) (map[string][]byte, bool, error) {
+	signer, err := parseKeyPair(data[CACertKey], data[CAKeyKey])
+	if err != nil || !now.Before(signer.cert.NotAfter) {
+		// nothing usable, start over with a new CA
+		ca, err := newCA(now, r.caValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		return r.issue(ca, []*x509.Certificate{ca.cert}, now)
+	}
+
+	trusted, _ := parseCerts(data[CACertKey])
+	changed := false
+
+	if signer.cert.NotAfter.Sub(now) < r.renewBefore {
+		ca, err := newCA(now, r.caValidity)
+		if err != nil {
+			return nil, false, err
+		}
+
+		signer = ca
+		trusted = append([]*x509.Certificate{ca.cert}, trusted...)
+		changed = true
+	}
+
+	n := len(trusted)
+	trusted = slices.DeleteFunc(trusted, func(c *x509.Certificate) bool {
+		return !now.Before(c.NotAfter)
+	})
+	changed = changed || len(trusted) != n
+
+	serving, err := parseKeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil || !slices.ContainsFunc(trusted, func(ca *x509.Certificate) bool {
+		return validFor(serving.cert, ca, r.dnsNames, now)
+	}) {
+		// the current certificate is unusable, replace it right away
+		return r.issue(signer, trusted, now)
+	}
+
+	propagated := now.Sub(signer.cert.NotBefore) >= r.propagationDelay
+	expiring := serving.cert.NotAfter.Sub(now) < r.renewBefore
+	stale := serving.cert.CheckSignatureFrom(signer.cert) != nil
+
+	if propagated && (expiring || stale) {
+		return r.issue(signer, trusted, now)
+	}
+
+	if !changed {
+		return data, false, nil
+	}
+
+	caKey, err := encodeKey(signer.key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return map[string][]byte{
+		CACertKey:               encodeCerts(trusted...),
+		CAKeyKey:                caKey,
+		corev1.TLSCertKey:       data[corev1.TLSCertKey],
+		corev1.TLSPrivateKeyKey: data[corev1.TLSPrivateKeyKey],
+	}, true, nil
+}
+
+// issue returns Secret data with a new serving certificate signed by ca.
+func (r *Rotator) issue(ca *keyPair, trusted []*x509.Certificate, now time.Time) (map[string][]byte, bool, error) {
+	serving, err := newServingCert(ca, r.dnsNames, now, r.certValidity)
+	if err != nil {
+		return nil, false, err
+	}
+
+	caKey, err := encodeKey(ca.key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key, err := encodeKey(serving.key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return map[string][]byte{
+		CACertKey:               encodeCerts(trusted...),
+		CAKeyKey:                caKey,
+		corev1.TLSCertKey:       encodeCerts(serving.cert),
+		corev1.TLSPrivateKeyKey: key,
+	}, true, nil
+}
+
+// load writes the serving certificate to disk and keeps the CA bundle for the webhook configuration.
+func (r *Rotator) load(data map[string][]byte) error {
+	serving, err := parseKeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+
+	// the key is written first, the certificate watcher reloads both once the certificate changes
+	for _, key := range []string{corev1.TLSPrivateKeyKey, corev1.TLSCertKey} {
+		if err := writeFile(filepath.Join(r.certDir, key), data[key]); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	rotated := !bytes.Equal(r.caBundle, data[CACertKey])
+	r.caBundle = data[CACertKey]
+	r.notAfter = serving.cert.NotAfter
+	r.mu.Unlock()
+
+	if rotated {
+		r.onRotate()
+	}
+
+	return nil
+}
+
+// writeFile atomically replaces the file when its content differs.
+func writeFile(path string, data []byte) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
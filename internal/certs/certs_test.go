@@ -0,0 +1,221 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testNamespace = "hedgetrimmer"
+	testSecret    = "hedgetrimmer-webhook-certs"
+)
+
+type testRotator struct {
+	*Rotator
+	clock    time.Time
+	rotated  int
+	certDir  string
+	clientCS *fake.Clientset
+}
+
+func newTestRotator(t *testing.T, objects ...*corev1.Secret) *testRotator {
+	cs := fake.NewSimpleClientset()
+	for _, o := range objects {
+		_, err := cs.CoreV1().Secrets(o.Namespace).Create(context.Background(), o, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	tr := &testRotator{
+		clock:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		certDir:  t.TempDir(),
+		clientCS: cs,
+	}
+
+	tr.Rotator = NewRotator(cs, testNamespace, testSecret, tr.certDir, DNSNames(testNamespace, "hedgetrimmer"),
+		WithCAValidity(365*24*time.Hour),
+		WithCertValidity(90*24*time.Hour),
+		WithRenewBefore(30*24*time.Hour),
+		WithOnRotate(func() { tr.rotated++ }),
+	)
+	tr.Rotator.now = func() time.Time { return tr.clock }
+
+	return tr
+}
+
+func (tr *testRotator) secret(t *testing.T) *corev1.Secret {
+	secret, err := tr.clientCS.CoreV1().Secrets(testNamespace).Get(context.Background(), testSecret, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return secret
+}
+
+func (tr *testRotator) certs(t *testing.T) (trusted []*x509.Certificate, serving *x509.Certificate) {
+	secret := tr.secret(t)
+
+	trusted, err := parseCerts(secret.Data[CACertKey])
+	assert.NoError(t, err)
+
+	servingCerts, err := parseCerts(secret.Data[corev1.TLSCertKey])
+	assert.NoError(t, err)
+
+	return trusted, servingCerts[0]
+}
+
+func TestEnsureCreatesCertificates(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestRotator(t)
+	ctx := context.Background()
+
+	assert.NoError(t, tr.Ensure(ctx))
+	assert.Equal(t, 1, tr.rotated)
+
+	trusted, serving := tr.certs(t)
+	assert.Len(t, trusted, 1)
+	assert.True(t, validFor(serving, trusted[0], DNSNames(testNamespace, "hedgetrimmer"), tr.clock))
+	assert.Equal(t, serving.NotAfter, tr.NotAfter())
+
+	bundle, err := tr.CABundle(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, tr.secret(t).Data[CACertKey], bundle)
+
+	// the files on disk form a usable key pair
+	_, err = tls.LoadX509KeyPair(filepath.Join(tr.certDir, corev1.TLSCertKey), filepath.Join(tr.certDir, corev1.TLSPrivateKeyKey))
+	assert.NoError(t, err)
+
+	// nothing changes while the certificates are valid
+	resourceVersion := tr.secret(t).ResourceVersion
+	tr.clock = tr.clock.Add(24 * time.Hour)
+	assert.NoError(t, tr.Ensure(ctx))
+	assert.Equal(t, resourceVersion, tr.secret(t).ResourceVersion)
+	assert.Equal(t, 1, tr.rotated)
+}
+
+func TestEnsureRenewsServingCertificate(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestRotator(t)
+	ctx := context.Background()
+	assert.NoError(t, tr.Ensure(ctx))
+	trusted, serving := tr.certs(t)
+
+	tr.clock = tr.clock.Add(61 * 24 * time.Hour)
+	assert.NoError(t, tr.Ensure(ctx))
+
+	renewedTrusted, renewed := tr.certs(t)
+	assert.Equal(t, trusted, renewedTrusted, "the CA is kept")
+	assert.NotEqual(t, serving.SerialNumber, renewed.SerialNumber)
+	assert.NoError(t, renewed.CheckSignatureFrom(trusted[0]))
+	assert.Equal(t, 1, tr.rotated, "the CA bundle did not change")
+
+	onDisk, err := os.ReadFile(filepath.Join(tr.certDir, corev1.TLSCertKey))
+	assert.NoError(t, err)
+	assert.Equal(t, tr.secret(t).Data[corev1.TLSCertKey], onDisk)
+}
+
+func TestEnsureStagesNewCA(t *testing.T) {
+	t.Parallel()
+
+	tr := newTestRotator(t)
+	ctx := context.Background()
+	assert.NoError(t, tr.Ensure(ctx))
+	trusted, _ := tr.certs(t)
+	oldCA := trusted[0]
+
+	// keep renewing the serving certificate until the CA enters its renewal window
+	start := tr.clock
+	for i := 0; i < 5; i++ {
+		tr.clock = tr.clock.Add(61 * 24 * time.Hour)
+		assert.NoError(t, tr.Ensure(ctx))
+	}
+	tr.clock = start.Add(340 * 24 * time.Hour)
+	assert.NoError(t, tr.Ensure(ctx))
+
+	trusted, serving := tr.certs(t)
+	assert.Len(t, trusted, 2, "the new CA is trusted next to the old one")
+	assert.Equal(t, oldCA.Raw, trusted[1].Raw)
+	assert.NoError(t, serving.CheckSignatureFrom(oldCA), "the serving certificate waits for the new CA to propagate")
+	assert.Equal(t, 2, tr.rotated)
+
+	tr.clock = tr.clock.Add(10 * time.Minute)
+	assert.NoError(t, tr.Ensure(ctx))
+
+	trusted, serving = tr.certs(t)
+	assert.Len(t, trusted, 2)
+	assert.NoError(t, serving.CheckSignatureFrom(trusted[0]), "the serving certificate is signed by the new CA")
+
+	// the old CA is dropped once it expired
+	tr.clock = oldCA.NotAfter.Add(time.Minute)
+	assert.NoError(t, tr.Ensure(ctx))
+
+	trusted, serving = tr.certs(t)
+	assert.Len(t, trusted, 1)
+	assert.NoError(t, serving.CheckSignatureFrom(trusted[0]))
+	assert.Equal(t, 3, tr.rotated)
+}
+
+func TestEnsureReplacesUnusableCertificates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg       string
+		data      func(valid map[string][]byte) map[string][]byte
+		wantNewCA bool
+	}{
+		{
+			msg: "Corrupt CA",
+			data: func(valid map[string][]byte) map[string][]byte {
+				valid[CAKeyKey] = []byte("invalid")
+				return valid
+			},
+			wantNewCA: true,
+		},
+		{
+			msg: "Missing serving certificate",
+			data: func(valid map[string][]byte) map[string][]byte {
+				delete(valid, corev1.TLSCertKey)
+				return valid
+			},
+		},
+		{
+			msg: "Serving certificate for other DNS names",
+			data: func(valid map[string][]byte) map[string][]byte {
+				other := newTestRotator(t)
+				other.dnsNames = DNSNames("other", "hedgetrimmer")
+				data, _, err := other.rotate(nil, other.clock)
+				assert.NoError(t, err)
+
+				valid[corev1.TLSCertKey] = data[corev1.TLSCertKey]
+				valid[corev1.TLSPrivateKeyKey] = data[corev1.TLSPrivateKeyKey]
+				return valid
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tr := newTestRotator(t)
+		valid, _, err := tr.rotate(nil, tr.clock)
+		assert.NoError(t, err, test.msg)
+		oldCA, err := parseCerts(valid[CACertKey])
+		assert.NoError(t, err, test.msg)
+
+		tr = newTestRotator(t, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testSecret},
+			Data:       test.data(valid),
+		})
+		assert.NoError(t, tr.Ensure(context.Background()), test.msg)
+
+		trusted, serving := tr.certs(t)
+		assert.True(t, validFor(serving, trusted[0], tr.dnsNames, tr.clock), test.msg)
+		assert.Equal(t, test.wantNewCA, trusted[0].SerialNumber.Cmp(oldCA[0].SerialNumber) != 0, test.msg)
+	}
+}
@@ -2,28 +2,42 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	nodev1listers "k8s.io/client-go/listers/node/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/kanopy-platform/hedgetrimmer/internal/admission"
+	"github.com/kanopy-platform/hedgetrimmer/internal/audit"
+	"github.com/kanopy-platform/hedgetrimmer/internal/bootstrap"
+	"github.com/kanopy-platform/hedgetrimmer/internal/certs"
+	"github.com/kanopy-platform/hedgetrimmer/internal/health"
 	logzap "github.com/kanopy-platform/hedgetrimmer/internal/log/zap"
-	pkgadmission "github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/kanopy-platform/hedgetrimmer/internal/manifest"
+	"github.com/kanopy-platform/hedgetrimmer/internal/policyreport"
+	"github.com/kanopy-platform/hedgetrimmer/internal/webhookconfig"
 	pkghandlers "github.com/kanopy-platform/hedgetrimmer/pkg/admission/handlers"
+	policyreportv1alpha2 "github.com/kanopy-platform/hedgetrimmer/pkg/apis/policyreport/v1alpha2"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	klog "sigs.k8s.io/controller-runtime/pkg/log"
 	k8szap "sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -36,6 +50,13 @@ import (
 
 var scheme = runtime.NewScheme()
 
+func init() {
+	// the built-in types are managed by the bootstrap controller and referenced by Events
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(policyreportv1alpha2.AddToScheme(scheme))
+	utilruntime.Must(admissionregistrationv1.AddToScheme(scheme))
+}
+
 type RootCommand struct {
 	k8sFlags *genericclioptions.ConfigFlags
 }
@@ -56,14 +77,47 @@ func NewRootCommand() *cobra.Command {
 	cmd.PersistentFlags().Int("metrics-listen-port", 8081, "Metrics listen port")
 	cmd.PersistentFlags().String("webhook-certs-dir", "/etc/webhook/certs", "Admission webhook TLS certificate directory")
 	cmd.PersistentFlags().Bool("dry-run", false, "Controller dry-run changes only")
+	cmd.PersistentFlags().Duration("informer-stall-timeout", 5*time.Minute, "Fail the liveness probe once the LimitRange informer failed to watch for this long")
+	cmd.PersistentFlags().Float64("limitrange-fallback-qps", 5, "Rate of LimitRange lookups through the API server while the cache is not synced or for new namespaces, 0 disables the fallback")
+	cmd.PersistentFlags().Int("limitrange-fallback-burst", 10, "Burst of LimitRange lookups through the API server")
+	cmd.PersistentFlags().Duration("limitrange-fallback-namespace-age", 1*time.Minute, "Namespaces younger than this are looked up through the API server when the cache has no LimitRange")
 	cmd.PersistentFlags().Float64("default-memory-limit-request-ratio", 1.1, "Default memory limit/request ratio")
+	cmd.PersistentFlags().String("init-container-policy", string(mutators.InitContainerPolicyDefault), "Init container policy: default (same logic as app containers), copy-largest (copy missing values from the largest app container) or skip")
+	cmd.PersistentFlags().Float64("init-container-memory-limit-request-ratio", 0, "Default memory limit/request ratio of init containers, 0 uses --default-memory-limit-request-ratio")
 	cmd.PersistentFlags().StringSlice("resources", all_resources, "List of resources to enforce")
+	cmd.PersistentFlags().String("default-limitrange-file", "", "LimitRange manifest applied in namespaces without a Container LimitRange")
+	cmd.PersistentFlags().Bool("deny-unconfigured-namespaces", false, "Deny the creation of workloads in namespaces without a Container LimitRange")
+	cmd.PersistentFlags().StringSlice("limitrange-templates", nil, "LimitRange manifests created in namespaces without a LimitRange, selected by the namespace label selector in the annotation "+bootstrap.NamespaceSelectorAnnotation)
+	cmd.PersistentFlags().Bool("webhook-config", false, "Create and reconcile the MutatingWebhookConfiguration for the enforced resources")
+	cmd.PersistentFlags().String("webhook-config-name", webhookconfig.DefaultName, "Name of the MutatingWebhookConfiguration")
+	cmd.PersistentFlags().String("webhook-service", "hedgetrimmer/hedgetrimmer", "Namespace and name of the webhook Service")
+	cmd.PersistentFlags().Int32("webhook-service-port", 8443, "Port of the webhook Service")
+	cmd.PersistentFlags().String("webhook-ca-bundle-file", "", "CA bundle injected into the webhook configuration, defaults to ca.crt in the webhook certificate directory, the existing bundle is kept when the file is missing")
+	cmd.PersistentFlags().String("webhook-namespace-selector", "kubernetes.io/metadata.name notin (kube-system)", "Label selector for namespaces sent to the webhook")
+	cmd.PersistentFlags().String("webhook-failure-policy", string(admissionregistrationv1.Ignore), "Webhook failure policy: Ignore or Fail")
+	cmd.PersistentFlags().Int32("webhook-timeout-seconds", 10, "Webhook timeout in seconds")
+	cmd.PersistentFlags().Bool("webhook-certs-generate", false, "Generate a self-signed CA and serving certificate into a Secret, rotate them before expiry and write the serving certificate into the webhook certificate directory, which must be writable. Requires --webhook-config to inject the CA bundle")
+	cmd.PersistentFlags().String("webhook-certs-secret", "hedgetrimmer-webhook-certs", "Name of the Secret holding the generated certificates, in the namespace of the webhook Service")
+	cmd.PersistentFlags().Bool("validate-limitranges", false, "Serve the /validate-limitrange endpoint on the webhook server, denying inconsistent LimitRanges and warning about defaults the mutator would deny")
+	cmd.PersistentFlags().Bool("simulate", false, "Serve the authenticated /simulate endpoint on the webhook server")
+	cmd.PersistentFlags().Bool("policy-reports", false, "Write admission and audit results into wgpolicyk8s.io PolicyReports")
+	cmd.PersistentFlags().Duration("policy-report-result-ttl", 24*time.Hour, "Age after which admission results are dropped from the PolicyReports, 0 keeps them until they are replaced")
+	cmd.PersistentFlags().Int("policy-report-max-results", 1000, "Maximum number of results per PolicyReport, failures and warnings are kept over passing results")
+	cmd.PersistentFlags().String("message-templates", "", "YAML file mapping message names ("+strings.Join(mutators.MessageNames(), ", ")+") to Go templates replacing the default denial and warning messages")
+	cmd.PersistentFlags().String("documentation-url", "", "URL linked from denials and warnings")
+	cmd.PersistentFlags().Bool("limitrange-impact-analysis", false, "Evaluate the workloads of a namespace when its LimitRange changes and report the ones which would be denied or re-defaulted as an Event on the LimitRange")
+	cmd.PersistentFlags().Duration("audit-interval", 0, "Interval between audits of existing workloads, 0 disables the audit")
+	cmd.PersistentFlags().String("enforcement-mode", string(limitrange.EnforcementModeMutate), "Enforcement mode: mutate, suggest (deny with suggested values) or warn (allow with suggested values), can be overridden per namespace with the LimitRange annotation "+limitrange.EnforcementModeAnnotation)
 
 	k8sFlags.AddFlags(cmd.PersistentFlags())
 	// no need to check err, this only checks if variadic args != 0
 	_ = viper.BindEnv("kubeconfig", "KUBECONFIG")
 
 	cmd.AddCommand(newVersionCommand())
+	cmd.AddCommand(newMutateCommand(root))
+	cmd.AddCommand(newKRMCommand(root))
+	cmd.AddCommand(newPostRenderCommand(root))
+	cmd.AddCommand(newExplainCommand(root))
 	return cmd
 }
 
@@ -94,6 +148,11 @@ func (c *RootCommand) persistentPreRunE(cmd *cobra.Command, args []string) error
 }
 
 func (c *RootCommand) runE(cmd *cobra.Command, args []string) error {
+	// rotated CAs are only injected by the webhook configuration reconciler
+	if viper.GetBool("webhook-certs-generate") && !viper.GetBool("webhook-config") {
+		return fmt.Errorf("--webhook-certs-generate requires --webhook-config")
+	}
+
 	dryRun := viper.GetBool("dry-run")
 	if dryRun {
 		klog.Log.Info("running in dry-run mode.")
@@ -126,9 +185,6 @@ func (c *RootCommand) runE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := configureHealthChecks(mgr); err != nil {
-		return err
-	}
 	cs, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return err
@@ -137,22 +193,49 @@ func (c *RootCommand) runE(cmd *cobra.Command, args []string) error {
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(cs, 1*time.Minute)
 
 	lri := informerFactory.Core().V1().LimitRanges()
-	_, err = lri.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(new interface{}) {},
-	})
+	watchdog, err := health.NewInformerWatchdog("limitrange", lri.Informer(), viper.GetDuration("informer-stall-timeout"))
 	if err != nil {
 		return err
 	}
 
-	informerFactory.Start(wait.NeverStop)
-	informerFactory.WaitForCacheSync(wait.NeverStop)
+	var limitRangerOpts []limitrange.OptionsFunc
+	if qps := viper.GetFloat64("limitrange-fallback-qps"); qps > 0 {
+		nsi := informerFactory.Core().V1().Namespaces()
+		limitRangerOpts = append(limitRangerOpts,
+			limitrange.WithLiveFallback(cs, float32(qps), viper.GetInt("limitrange-fallback-burst")),
+			limitrange.WithHasSynced(lri.Informer().HasSynced),
+			limitrange.WithNamespaceLister(nsi.Lister(), viper.GetDuration("limitrange-fallback-namespace-age")),
+		)
+	}
 
-	limitRanger := limitrange.NewLimitRanger(lri.Lister())
+	limitRanger := limitrange.NewLimitRanger(lri.Lister(), limitRangerOpts...)
+	if _, err := lri.Informer().AddEventHandler(limitRanger.ResourceEventHandler()); err != nil {
+		return err
+	}
 
-	ptm := mutators.NewPodTemplateSpec(
-		mutators.WithDefaultMemoryLimitRequestRatio(viper.GetFloat64("default-memory-limit-request-ratio")),
-		mutators.WithDryRun(viper.GetBool("dry-run")),
-	)
+	if _, err := informerFactory.Core().V1().Namespaces().Informer().AddEventHandler(limitRanger.NamespaceEventHandler()); err != nil {
+		return err
+	}
+
+	if err := mgr.AddMetricsServerExtraHandler("/debug/limitranges", limitRanger); err != nil {
+		return err
+	}
+
+	// pod templates get the RuntimeClass overhead only once their pods are admitted
+	rci := informerFactory.Node().V1().RuntimeClasses()
+	runtimeClasses := rci.Lister()
+
+	ptm, err := newPodTemplateSpecMutator(mutators.WithRuntimeClassLister(runtimeClasses))
+	if err != nil {
+		return err
+	}
+
+	// readiness holds back admission traffic until the cache is synced
+	informerFactory.Start(ctx.Done())
+
+	if err := configureHealthChecks(mgr, lri.Informer().HasSynced, watchdog); err != nil {
+		return err
+	}
 
 	decoder := webhookadmission.NewDecoder(mgr.GetScheme())
 
@@ -161,32 +244,359 @@ func (c *RootCommand) runE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	admissionRouter, err := admission.NewRouter(limitRanger,
+	unconfiguredOpts, err := unconfiguredNamespaceOptions()
+	if err != nil {
+		return err
+	}
+
+	routerOpts := append([]admission.OptionsFunc{
 		admission.WithAdmissionHandlers(handlers...),
-	)
+		admission.WithDocumentationURL(viper.GetString("documentation-url")),
+	}, unconfiguredOpts...)
+	var reportHandlers []audit.ReportHandler
+
+	if viper.GetBool("policy-reports") {
+		// reports are written without a cache to avoid watching every PolicyReport in the cluster
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			return err
+		}
+
+		reporter := policyreport.NewReporter(c,
+			policyreport.WithResultTTL(viper.GetDuration("policy-report-result-ttl")),
+			policyreport.WithMaxResults(viper.GetInt("policy-report-max-results")),
+		)
+		if err := reporter.SetupWithManager(mgr); err != nil {
+			return err
+		}
+
+		routerOpts = append(routerOpts, admission.WithRecorders(reporter))
+		reportHandlers = append(reportHandlers, reporter)
+	}
+
+	admissionRouter, err := admission.NewRouter(limitRanger, routerOpts...)
 	if err != nil {
 		return err
 	}
 
 	admissionRouter.SetupWithManager(mgr)
 
+	var rotator *certs.Rotator
+	var reconciler *webhookconfig.Reconciler
+
+	if viper.GetBool("webhook-certs-generate") {
+		rotator, err = newCertRotator(cs, func() {
+			// inject the new CA bundle into the webhook configuration
+			if reconciler != nil {
+				reconciler.Refresh()
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if viper.GetBool("webhook-config") {
+		var caBundle webhookconfig.CABundleSource
+		if rotator != nil {
+			caBundle = rotator
+		}
+
+		if reconciler, err = setupWebhookConfig(mgr, admissionRouter.Kinds(), caBundle); err != nil {
+			return err
+		}
+	}
+
+	if rotator != nil {
+		// the webhook server loads the serving certificate when it starts
+		if err := rotator.Ensure(ctx); err != nil {
+			return err
+		}
+
+		if err := rotator.SetupWithManager(mgr); err != nil {
+			return err
+		}
+	}
+
+	if files := viper.GetStringSlice("limitrange-templates"); len(files) > 0 {
+		if err := setupBootstrap(mgr, files); err != nil {
+			return err
+		}
+	}
+
+	if viper.GetBool("validate-limitranges") {
+		admission.NewLimitRangeValidator(decoder, ptm).SetupWithManager(mgr)
+	}
+
+	if viper.GetBool("simulate") {
+		admission.NewSimulator(admissionRouter, admission.NewTokenReviewAuthenticator(cs), admission.NewSubjectAccessReviewAuthorizer(cs)).SetupWithManager(mgr)
+	}
+
+	if viper.GetBool("limitrange-impact-analysis") {
+		if err := setupImpactAnalyzer(mgr, cs, lri, runtimeClasses, decoder); err != nil {
+			return err
+		}
+	}
+
+	if interval := viper.GetDuration("audit-interval"); interval > 0 {
+		synced := []cache.InformerSynced{lri.Informer().HasSynced, rci.Informer().HasSynced}
+		if err := setupAuditor(mgr, cs, limitRanger, runtimeClasses, synced, decoder, interval, reportHandlers...); err != nil {
+			return err
+		}
+	}
+
 	return mgr.Start(ctx)
 }
 
-func configureHealthChecks(mgr manager.Manager) error {
+// setupAuditor evaluates existing workloads with a mutator which is never in dry-run or suggest mode, so the audit
+// reports what the webhook would change even when enforcement is relaxed. The audit never writes to the cluster.
+func setupAuditor(mgr manager.Manager, cs kubernetes.Interface, limitRanger admission.LimitRanger, runtimeClasses nodev1listers.RuntimeClassLister, synced []cache.InformerSynced, decoder webhookadmission.Decoder, interval time.Duration, reportHandlers ...audit.ReportHandler) error {
+	router, err := newAuditRouter(limitRanger, runtimeClasses, decoder)
+	if err != nil {
+		return err
+	}
+
+	listers, err := getListers(viper.GetStringSlice("resources"), cs, metav1.NamespaceAll)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewAuditor(router, listers,
+		audit.WithInterval(interval),
+		audit.WithCacheSyncs(synced...),
+		audit.WithReportHandlers(reportHandlers...),
+	).SetupWithManager(mgr)
+}
+
+// newAuditRouter returns a router with a mutator which is never in dry-run or suggest mode, neither from the flags nor
+// from the enforcement mode annotation of a LimitRange.
+func newAuditRouter(limitRanger admission.LimitRanger, runtimeClasses nodev1listers.RuntimeClassLister, decoder webhookadmission.Decoder) (*admission.Router, error) {
+	ptm, err := newPodTemplateSpecMutator(
+		mutators.WithDryRun(false),
+		mutators.WithEnforcementMode(limitrange.EnforcementModeMutate),
+		mutators.WithLimitRangeEnforcementMode(false),
+		mutators.WithRuntimeClassLister(runtimeClasses),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers, err := getHandlers(viper.GetStringSlice("resources"), decoder, ptm)
+	if err != nil {
+		return nil, err
+	}
+
+	unconfiguredOpts, err := unconfiguredNamespaceOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return admission.NewRouter(limitRanger, append([]admission.OptionsFunc{admission.WithAdmissionHandlers(handlers...)}, unconfiguredOpts...)...)
+}
+
+// staticLimitRanger serves the same Config for every namespace.
+type staticLimitRanger struct {
+	cfg *limitrange.Config
+}
+
+func (s staticLimitRanger) LimitRangeConfig(namespace string) (*limitrange.Config, error) {
+	return s.cfg, nil
+}
+
+// setupImpactAnalyzer reports the existing workloads a LimitRange update would deny or re-default, like the audit
+// it never writes to the workloads.
+func setupImpactAnalyzer(mgr manager.Manager, cs kubernetes.Interface, lri corev1informers.LimitRangeInformer, runtimeClasses nodev1listers.RuntimeClassLister, decoder webhookadmission.Decoder) error {
+	resources := viper.GetStringSlice("resources")
+
+	analyzer := audit.NewImpactAnalyzer(lri.Lister(),
+		func(cfg *limitrange.Config) (webhookadmission.Handler, error) {
+			return newAuditRouter(staticLimitRanger{cfg: cfg}, runtimeClasses, decoder)
+		},
+		func(namespace string) ([]audit.Lister, error) {
+			return getListers(resources, cs, namespace)
+		},
+		mgr.GetEventRecorderFor("hedgetrimmer"),
+	)
+
+	if _, err := lri.Informer().AddEventHandler(analyzer.ResourceEventHandler()); err != nil {
+		return err
+	}
+
+	return analyzer.SetupWithManager(mgr)
+}
+
+// unconfiguredNamespaceOptions returns the router options for namespaces without a Container LimitRange, including the
+// messages they are denied with.
+func unconfiguredNamespaceOptions() ([]admission.OptionsFunc, error) {
+	cfg, err := defaultConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := messageTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	return []admission.OptionsFunc{
+		admission.WithMessages(messages),
+		admission.WithDefaultConfig(cfg),
+		admission.WithDenyUnconfigured(viper.GetBool("deny-unconfigured-namespaces")),
+	}, nil
+}
+
+// defaultConfig returns the Config read from --default-limitrange-file, or nil.
+func defaultConfig() (*limitrange.Config, error) {
+	file := viper.GetString("default-limitrange-file")
+	if file == "" {
+		return nil, nil
+	}
+
+	objects, err := manifest.Read([]string{file}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := manifest.LimitRanges(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := limitrange.CompileConfig(ranges...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg == nil {
+		return nil, fmt.Errorf("no Container limit found in %s", file)
+	}
+
+	return cfg, nil
+}
+
+// setupBootstrap creates LimitRanges from the templates in new namespaces.
+func setupBootstrap(mgr manager.Manager, files []string) error {
+	objects, err := manifest.Read(files, nil)
+	if err != nil {
+		return err
+	}
+
+	ranges, err := manifest.LimitRanges(objects)
+	if err != nil {
+		return err
+	}
+
+	templates, err := bootstrap.NewTemplates(ranges...)
+	if err != nil {
+		return err
+	}
+
+	if len(templates) == 0 {
+		return fmt.Errorf("no LimitRange found in %s", strings.Join(files, ", "))
+	}
+
+	return bootstrap.NewReconciler(mgr.GetClient(), templates).SetupWithManager(mgr)
+}
+
+// webhookService returns the namespace and name of the webhook Service.
+func webhookService() (string, string, error) {
+	namespace, name, ok := strings.Cut(viper.GetString("webhook-service"), "/")
+	if !ok {
+		return "", "", fmt.Errorf("invalid webhook service, expected namespace/name: %s", viper.GetString("webhook-service"))
+	}
+	return namespace, name, nil
+}
+
+func newCertRotator(cs kubernetes.Interface, onRotate func()) (*certs.Rotator, error) {
+	namespace, name, err := webhookService()
+	if err != nil {
+		return nil, err
+	}
+
+	return certs.NewRotator(cs, namespace, viper.GetString("webhook-certs-secret"), viper.GetString("webhook-certs-dir"),
+		certs.DNSNames(namespace, name),
+		certs.WithOnRotate(onRotate),
+	), nil
+}
+
+// setupWebhookConfig reconciles the webhook configuration, the CA bundle is read from the certificate directory
+// unless a source is given.
+func setupWebhookConfig(mgr manager.Manager, kinds []string, caBundle webhookconfig.CABundleSource) (*webhookconfig.Reconciler, error) {
+	rules, err := webhookconfig.Rules(kinds)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name, err := webhookService()
+	if err != nil {
+		return nil, err
+	}
+	port := viper.GetInt32("webhook-service-port")
+
+	selector, err := metav1.ParseToLabelSelector(viper.GetString("webhook-namespace-selector"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook namespace selector: %w", err)
+	}
+
+	failurePolicy := admissionregistrationv1.FailurePolicyType(viper.GetString("webhook-failure-policy"))
+	if failurePolicy != admissionregistrationv1.Ignore && failurePolicy != admissionregistrationv1.Fail {
+		return nil, fmt.Errorf("invalid webhook failure policy: %s", failurePolicy)
+	}
+
+	if caBundle == nil {
+		caBundleFile := viper.GetString("webhook-ca-bundle-file")
+		if caBundleFile == "" {
+			caBundleFile = filepath.Join(viper.GetString("webhook-certs-dir"), "ca.crt")
+		}
+		caBundle = webhookconfig.FileCABundle(caBundleFile)
+	}
+
+	reconciler := webhookconfig.NewReconciler(mgr.GetClient(),
+		admissionregistrationv1.ServiceReference{Namespace: namespace, Name: name, Port: &port},
+		rules,
+		webhookconfig.WithName(viper.GetString("webhook-config-name")),
+		webhookconfig.WithCABundleSource(caBundle),
+		webhookconfig.WithNamespaceSelector(selector),
+		webhookconfig.WithFailurePolicy(failurePolicy),
+		webhookconfig.WithTimeoutSeconds(viper.GetInt32("webhook-timeout-seconds")),
+	)
+
+	return reconciler, reconciler.SetupWithManager(mgr)
+}
+
+func configureHealthChecks(mgr manager.Manager, limitRangesSynced cache.InformerSynced, watchdog *health.InformerWatchdog) error {
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		return err
 	}
 
+	if err := mgr.AddHealthzCheck("limitrange-informer", watchdog.Check); err != nil {
+		return err
+	}
+
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		return err
 	}
+
+	if err := mgr.AddReadyzCheck("limitrange-cache", health.InformerSynced("limitrange", limitRangesSynced)); err != nil {
+		return err
+	}
+
+	if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		return err
+	}
+
+	certPath := filepath.Join(viper.GetString("webhook-certs-dir"), "tls.crt")
+	if err := mgr.AddReadyzCheck("certificate", health.CertificateValid(certPath)); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func getHandlers(resources []string, decoder webhookadmission.Decoder, ptm pkgadmission.PodTemplateSpecMutator) ([]admission.AdmissionHandler, error) {
+func getHandlers(resources []string, decoder webhookadmission.Decoder, ptm *mutators.PodTemplateSpec) ([]admission.AdmissionHandler, error) {
 	var handlers []admission.AdmissionHandler
 	var unexpected []string
+	messages := ptm.Messages()
 
 	dedupedResources := make(map[string]bool)
 	for _, resource := range resources {
@@ -196,21 +606,25 @@ func getHandlers(resources []string, decoder webhookadmission.Decoder, ptm pkgad
 	for resource := range dedupedResources {
 		switch resource {
 		case cronjobs:
-			handlers = append(handlers, pkghandlers.NewCronjobHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewCronjobHandler(decoder, ptm, messages))
 		case daemonsets:
-			handlers = append(handlers, pkghandlers.NewDaemonSetHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewDaemonSetHandler(decoder, ptm, messages))
 		case deployments:
-			handlers = append(handlers, pkghandlers.NewDeploymentHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewDeploymentHandler(decoder, ptm, messages))
 		case jobs:
-			handlers = append(handlers, pkghandlers.NewJobHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewJobHandler(decoder, ptm, messages))
 		case pods:
-			handlers = append(handlers, pkghandlers.NewPodHandler(decoder, ptm))
+			handlers = append(handlers,
+				pkghandlers.NewPodHandler(decoder, ptm, messages),
+				pkghandlers.NewEphemeralContainersHandler(decoder),
+				pkghandlers.NewPodResizeHandler(decoder, ptm, messages),
+			)
 		case replicasets:
-			handlers = append(handlers, pkghandlers.NewReplicaSetHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewReplicaSetHandler(decoder, ptm, messages))
 		case replicationcontrollers:
-			handlers = append(handlers, pkghandlers.NewReplicationControllerHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewReplicationControllerHandler(decoder, ptm, messages))
 		case statefulsets:
-			handlers = append(handlers, pkghandlers.NewStatefulSetHandler(decoder, ptm))
+			handlers = append(handlers, pkghandlers.NewStatefulSetHandler(decoder, ptm, messages))
 		default:
 			unexpected = append(unexpected, resource)
 		}
@@ -1,11 +1,31 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/kanopy-platform/hedgetrimmer/internal/audit"
+	"github.com/kanopy-platform/hedgetrimmer/internal/bootstrap"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -24,7 +44,7 @@ func TestGetHandlers(t *testing.T) {
 		{
 			msg:       "Full list of resources",
 			resources: all_resources,
-			wantLen:   8,
+			wantLen:   10,
 			wantError: false,
 		},
 		{
@@ -59,3 +79,140 @@ func TestGetHandlers(t *testing.T) {
 		assert.Equal(t, test.wantError, err != nil, test.msg)
 	}
 }
+
+func TestGetListers(t *testing.T) {
+	t.Parallel()
+
+	cs := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "d", Namespace: "test-ns"},
+	})
+
+	listers, err := getListers([]string{deployments}, cs, metav1.NamespaceAll)
+	assert.NoError(t, err)
+	assert.Len(t, listers, 1)
+
+	objects, err := listers[0].List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, "Deployment", objects[0].GetObjectKind().GroupVersionKind().Kind)
+
+	_, err = getListers([]string{"unexpected"}, cs, metav1.NamespaceAll)
+	assert.Error(t, err)
+}
+
+func TestSchemeBootstrapController(t *testing.T) {
+	t.Parallel()
+
+	mgr, err := manager.New(&rest.Config{Host: "https://127.0.0.1:1"}, manager.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+	})
+	assert.NoError(t, err)
+
+	templates, err := bootstrap.NewTemplates(&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	assert.NoError(t, err)
+	assert.NoError(t, bootstrap.NewReconciler(mgr.GetClient(), templates).SetupWithManager(mgr))
+
+	for _, obj := range []runtime.Object{&corev1.Namespace{}, &corev1.LimitRange{}} {
+		_, err := apiutil.GVKForObject(obj, scheme)
+		assert.NoError(t, err, "%T", obj)
+	}
+}
+
+func TestSchemeLimitRangeEvents(t *testing.T) {
+	t.Parallel()
+
+	// the impact analyzer records Events on LimitRanges, the recorder drops Events on objects unknown to the scheme
+	broadcaster := record.NewBroadcaster()
+	defer broadcaster.Shutdown()
+
+	events := make(chan *corev1.Event, 1)
+	broadcaster.StartEventWatcher(func(e *corev1.Event) {
+		events <- e
+	})
+
+	recorder := broadcaster.NewRecorder(scheme, corev1.EventSource{Component: "hedgetrimmer"})
+	recorder.Event(&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "t"}}, corev1.EventTypeWarning, audit.ReasonLimitRangeImpact, "impact")
+
+	select {
+	case e := <-events:
+		assert.Equal(t, "LimitRange", e.InvolvedObject.Kind)
+		assert.Equal(t, audit.ReasonLimitRangeImpact, e.Reason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event recorded")
+	}
+}
+
+func TestAuditRouterIgnoresEnforcementMode(t *testing.T) {
+	cmd := NewRootCommand()
+	assert.NoError(t, viper.BindPFlags(cmd.PersistentFlags()))
+
+	b, err := json.Marshal(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "t"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app"}},
+		}}},
+	})
+	assert.NoError(t, err)
+
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Namespace: "t",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: b},
+	}}
+
+	for _, mode := range []limitrange.EnforcementMode{"", limitrange.EnforcementModeWarn, limitrange.EnforcementModeSuggest} {
+		cfg := &limitrange.Config{HasDefaultLimit: true, DefaultLimit: resource.MustParse("1Gi"), EnforcementMode: mode}
+
+		router, err := newAuditRouter(staticLimitRanger{cfg: cfg}, nil, admission.NewDecoder(scheme))
+		assert.NoError(t, err)
+
+		resp := router.Handle(context.Background(), req)
+		assert.True(t, resp.Allowed, "mode %q", mode)
+		assert.NotEmpty(t, resp.Patches, "mode %q", mode)
+	}
+}
+
+func TestGetListersPages(t *testing.T) {
+	t.Parallel()
+
+	owned := metav1.ObjectMeta{Name: "owned", Namespace: "test-ns", OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web"}}}
+	pages := map[string]*corev1.PodList{
+		"": {
+			ListMeta: metav1.ListMeta{Continue: "2"},
+			Items:    []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test-ns"}}, {ObjectMeta: owned}},
+		},
+		"2": {Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "test-ns"}}}},
+	}
+
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		assert.Equal(t, int64(listPageSize), opts.Limit)
+		return true, pages[opts.Continue], nil
+	})
+
+	listers, err := getListers([]string{pods}, cs, metav1.NamespaceAll)
+	assert.NoError(t, err)
+
+	objects, err := listers[0].List(context.Background())
+	assert.NoError(t, err)
+
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.(*corev1.Pod).Name)
+		assert.Equal(t, "Pod", obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	assert.Equal(t, []string{"a", "b"}, names, "pods are listed in pages without the pods owned by a controller")
+}
+
+func TestWebhookCertsGenerateRequiresWebhookConfig(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--log-level=error", "--webhook-certs-generate"})
+
+	assert.EqualError(t, cmd.Execute(), "--webhook-certs-generate requires --webhook-config")
+}
@@ -1,5 +1,12 @@
 package cli
 
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
 const (
 	cronjobs               = "cronjobs"
 	daemonsets             = "daemonsets"
@@ -21,3 +28,14 @@ var all_resources = []string{
 	replicationcontrollers,
 	statefulsets,
 }
+
+var resourceGroupVersions = map[string]schema.GroupVersion{
+	cronjobs:               batchv1.SchemeGroupVersion,
+	daemonsets:             appsv1.SchemeGroupVersion,
+	deployments:            appsv1.SchemeGroupVersion,
+	jobs:                   batchv1.SchemeGroupVersion,
+	pods:                   corev1.SchemeGroupVersion,
+	replicasets:            appsv1.SchemeGroupVersion,
+	replicationcontrollers: corev1.SchemeGroupVersion,
+	statefulsets:           appsv1.SchemeGroupVersion,
+}
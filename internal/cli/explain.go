@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/manifest"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+)
+
+type explainCommand struct {
+	*cobra.Command
+	root *RootCommand
+}
+
+func newExplainCommand(root *RootCommand) *cobra.Command {
+	cmd := explainCommand{Command: &cobra.Command{}, root: root}
+
+	cmd.Use = "explain [RESOURCE/NAME]"
+	cmd.Short = "Show the effective policy of a namespace"
+	cmd.Long = `Prints the configuration hedgetrimmer applies in the namespace selected with --namespace and, given a workload,
+the mutations or denial it would produce. Use the same flags as the deployed webhook for accurate results.`
+	cmd.Args = cobra.MaximumNArgs(1)
+	cmd.SilenceUsage = true
+
+	cmd.RunE = cmd.runE
+	return cmd.Command
+}
+
+func (c *explainCommand) runE(cmd *cobra.Command, args []string) error {
+	namespace := c.root.defaultNamespace()
+
+	cfg, err := c.root.k8sFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	ranges, err := listLimitRanges(cmd.Context(), cs, []string{namespace})
+	if err != nil {
+		return err
+	}
+
+	limitRanger, err := limitrange.NewStaticLimitRanger(ranges...)
+	if err != nil {
+		return err
+	}
+
+	lrConfig, err := limitRanger.LimitRangeConfig(namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := writeExplanation(cmd.OutOrStdout(), namespace, ranges, lrConfig); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	resource, name, err := parseResourceName(args[0])
+	if err != nil {
+		return err
+	}
+
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	gvr := resourceGroupVersions[resource].WithResource(resource)
+	obj, err := dc.Resource(gvr).Namespace(namespace).Get(cmd.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	evaluator, err := newEvaluator(ranges, nil, namespace)
+	if err != nil {
+		return err
+	}
+
+	result, err := evaluator.Evaluate(cmd.Context(), obj)
+	if err != nil {
+		return err
+	}
+
+	return writeResult(cmd.OutOrStdout(), result)
+}
+
+// parseResourceName splits RESOURCE/NAME, accepting singular resource names.
+func parseResourceName(arg string) (string, string, error) {
+	resource, name, ok := strings.Cut(arg, "/")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("expected RESOURCE/NAME, got %q", arg)
+	}
+
+	resource = strings.ToLower(resource)
+	if _, ok := resourceGroupVersions[resource]; !ok {
+		resource += "s"
+	}
+
+	if _, ok := resourceGroupVersions[resource]; !ok {
+		return "", "", fmt.Errorf("unsupported resource %q, expected one of %v", arg, all_resources)
+	}
+
+	return resource, name, nil
+}
+
+func writeExplanation(w io.Writer, namespace string, ranges []*corev1.LimitRange, lrConfig *limitrange.Config) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	resources := viper.GetStringSlice("resources")
+	sort.Strings(resources)
+
+	var names []string
+	for _, lr := range ranges {
+		names = append(names, lr.Name)
+	}
+
+	fmt.Fprintf(tw, "Namespace:\t%s\n", namespace)
+	fmt.Fprintf(tw, "LimitRanges:\t%s\n", valueOrNone(strings.Join(names, ", ")))
+	fmt.Fprintf(tw, "Enforced resources:\t%s\n", strings.Join(resources, ", "))
+	fmt.Fprintf(tw, "Dry-run:\t%t\n", viper.GetBool("dry-run"))
+	fmt.Fprintf(tw, "Default memory limit/request ratio:\t%v\n", viper.GetFloat64("default-memory-limit-request-ratio"))
+
+	var source string
+	if lrConfig != nil {
+		source = fmt.Sprintf("LimitRange %s", lrConfig.LimitRangeName)
+	} else {
+		if viper.GetBool("deny-unconfigured-namespaces") {
+			fmt.Fprintf(tw, "Policy:\tnone, no LimitRange of type Container, workloads are denied\n")
+			return tw.Flush()
+		}
+
+		cfg, err := defaultConfig()
+		if err != nil {
+			return err
+		}
+
+		if cfg == nil {
+			fmt.Fprintf(tw, "Policy:\tnone, no LimitRange of type Container, workloads are admitted unmodified\n")
+			return tw.Flush()
+		}
+
+		lrConfig = cfg
+		source = fmt.Sprintf("cluster default %s (%s)", lrConfig.LimitRangeName, viper.GetString("default-limitrange-file"))
+	}
+
+	mode := viper.GetString("enforcement-mode")
+	if lrConfig.EnforcementMode != "" {
+		mode = fmt.Sprintf("%s (%s annotation on %s)", lrConfig.EnforcementMode, limitrange.EnforcementModeAnnotation, lrConfig.LimitRangeName)
+	}
+
+	fmt.Fprintf(tw, "Policy source:\t%s\n", source)
+	fmt.Fprintf(tw, "Enforcement mode:\t%s\n", mode)
+	fmt.Fprintf(tw, "Default memory request:\t%s\n", quantityOrNone(lrConfig.HasDefaultRequest, lrConfig.DefaultRequest.String()))
+	fmt.Fprintf(tw, "Default memory limit:\t%s\n", quantityOrNone(lrConfig.HasDefaultLimit, lrConfig.DefaultLimit.String()))
+	fmt.Fprintf(tw, "Max memory limit/request ratio:\t%s\n", quantityOrNone(lrConfig.HasMaxLimitRequestRatio, lrConfig.MaxLimitRequestRatio.String()))
+	fmt.Fprintf(tw, "Min memory:\t%s\n", quantityOrNone(lrConfig.HasMin, lrConfig.Min.String()))
+	fmt.Fprintf(tw, "Max memory:\t%s\n", quantityOrNone(lrConfig.HasMax, lrConfig.Max.String()))
+	fmt.Fprintf(tw, "Max pod memory:\t%s\n", quantityOrNone(lrConfig.HasPodMax, lrConfig.PodMax.String()))
+
+	return tw.Flush()
+}
+
+func writeResult(w io.Writer, result manifest.Result) error {
+	fmt.Fprintln(w)
+
+	switch {
+	case !result.Handled:
+		fmt.Fprintf(w, "%s: not handled by hedgetrimmer\n", result.Name())
+	case !result.Allowed:
+		fmt.Fprintf(w, "%s: would be denied: %s\n", result.Name(), result.Message)
+	case result.Mutates():
+		fmt.Fprintf(w, "%s: would be mutated:\n", result.Name())
+		diff, err := result.Diff()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, diff)
+	default:
+		fmt.Fprintf(w, "%s: compliant, no changes\n", result.Name())
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+
+	return nil
+}
+
+func quantityOrNone(ok bool, value string) string {
+	if !ok {
+		return "<none>"
+	}
+	return value
+}
+
+func valueOrNone(value string) string {
+	return quantityOrNone(value != "", value)
+}
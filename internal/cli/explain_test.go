@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+)
+
+func TestParseResourceName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		arg          string
+		wantResource string
+		wantName     string
+		wantError    bool
+	}{
+		{arg: "deployments/web", wantResource: deployments, wantName: "web"},
+		{arg: "CronJob/nightly", wantResource: cronjobs, wantName: "nightly"},
+		{arg: "services/web", wantError: true},
+		{arg: "deployments", wantError: true},
+	}
+
+	for _, test := range tests {
+		resource, name, err := parseResourceName(test.arg)
+		assert.Equal(t, test.wantError, err != nil, test.arg)
+		assert.Equal(t, test.wantResource, resource, test.arg)
+		assert.Equal(t, test.wantName, name, test.arg)
+	}
+}
+
+func TestWriteExplanation(t *testing.T) {
+	t.Parallel()
+
+	ranges := []*corev1.LimitRange{{ObjectMeta: metav1.ObjectMeta{Name: "limits"}}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeExplanation(out, "test-ns", ranges, &limitrange.Config{
+		HasDefaultRequest: true,
+		DefaultRequest:    resource.MustParse("64Mi"),
+		EnforcementMode:   limitrange.EnforcementModeWarn,
+		LimitRangeName:    "limits",
+	}))
+
+	assert.Contains(t, out.String(), "Policy source:")
+	assert.Regexp(t, `Default memory request:\s+64Mi`, out.String())
+	assert.Regexp(t, `Default memory limit:\s+<none>`, out.String())
+	assert.Regexp(t, `Enforcement mode:\s+warn \(`, out.String())
+
+	out.Reset()
+	assert.NoError(t, writeExplanation(out, "test-ns", nil, nil))
+	assert.Regexp(t, `LimitRanges:\s+<none>`, out.String())
+	assert.Contains(t, out.String(), "workloads are admitted unmodified")
+}
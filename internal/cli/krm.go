@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/manifest"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+)
+
+// ConfigMap data keys accepted as functionConfig.
+const (
+	krmDefaultMemoryRequest           = "defaultMemoryRequest"
+	krmDefaultMemoryLimit             = "defaultMemoryLimit"
+	krmMaxMemoryLimitRequestRatio     = "maxMemoryLimitRequestRatio"
+	krmDefaultMemoryLimitRequestRatio = "defaultMemoryLimitRequestRatio"
+	krmEnforcementMode                = "enforcementMode"
+)
+
+type krmCommand struct {
+	*cobra.Command
+	root *RootCommand
+}
+
+func newKRMCommand(root *RootCommand) *cobra.Command {
+	cmd := krmCommand{Command: &cobra.Command{}, root: root}
+
+	cmd.Use = "krm"
+	cmd.Short = "Run as a KRM function for kustomize and kpt"
+	cmd.Long = `Reads a ResourceList from stdin and writes it to stdout with every supported workload defaulted.
+The functionConfig is either a LimitRange or a ConfigMap with the keys defaultMemoryRequest, defaultMemoryLimit,
+maxMemoryLimitRequestRatio, defaultMemoryLimitRequestRatio and enforcementMode. LimitRanges among the items apply as well.`
+	cmd.SilenceUsage = true
+
+	cmd.RunE = cmd.runE
+	return cmd.Command
+}
+
+func (c *krmCommand) runE(cmd *cobra.Command, args []string) error {
+	rl, err := manifest.ReadResourceList(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	ranges, err := manifest.LimitRanges(rl.Items)
+	if err != nil {
+		return err
+	}
+
+	var opts []mutators.OptionsFunc
+	if rl.FunctionConfig != nil {
+		lr, fnOpts, err := functionConfig(rl.FunctionConfig)
+		if err != nil {
+			return err
+		}
+
+		if lr != nil {
+			ranges = append(ranges, lr)
+		}
+		opts = fnOpts
+	}
+
+	evaluator, err := newEvaluator(ranges, rl.Items, c.root.defaultNamespace(), opts...)
+	if err != nil {
+		return err
+	}
+
+	denied := 0
+	for idx, obj := range rl.Items {
+		result, err := evaluator.Evaluate(cmd.Context(), obj)
+		if err != nil {
+			return err
+		}
+
+		for _, warning := range result.Warnings {
+			rl.Results = append(rl.Results, manifest.ResourceListResult{
+				Message:     warning,
+				Severity:    manifest.SeverityWarning,
+				ResourceRef: manifest.NewResourceRef(obj),
+			})
+		}
+
+		if !result.Allowed {
+			denied++
+			rl.Results = append(rl.Results, manifest.ResourceListResult{
+				Message:     result.Message,
+				Severity:    manifest.SeverityError,
+				ResourceRef: manifest.NewResourceRef(obj),
+			})
+		}
+
+		rl.Items[idx] = result.Mutated
+	}
+
+	if err := manifest.WriteResourceList(cmd.OutOrStdout(), rl); err != nil {
+		return err
+	}
+
+	if denied > 0 {
+		return fmt.Errorf("%d object(s) would be denied", denied)
+	}
+
+	return nil
+}
+
+// functionConfig returns the LimitRange and mutator options described by a LimitRange or ConfigMap functionConfig.
+func functionConfig(fc *unstructured.Unstructured) (*corev1.LimitRange, []mutators.OptionsFunc, error) {
+	switch fc.GroupVersionKind() {
+	case corev1.SchemeGroupVersion.WithKind("LimitRange"):
+		lr := &corev1.LimitRange{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fc.Object, lr); err != nil {
+			return nil, nil, err
+		}
+		// the functionConfig applies to every namespace
+		lr.Namespace = ""
+		return lr, nil, nil
+	case corev1.SchemeGroupVersion.WithKind("ConfigMap"):
+		cm := &corev1.ConfigMap{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fc.Object, cm); err != nil {
+			return nil, nil, err
+		}
+		return functionConfigFromConfigMap(cm)
+	default:
+		return nil, nil, fmt.Errorf("unsupported functionConfig kind: %s", fc.GetKind())
+	}
+}
+
+func functionConfigFromConfigMap(cm *corev1.ConfigMap) (*corev1.LimitRange, []mutators.OptionsFunc, error) {
+	var opts []mutators.OptionsFunc
+
+	item := corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+	for key, list := range map[string]*corev1.ResourceList{
+		krmDefaultMemoryRequest:       &item.DefaultRequest,
+		krmDefaultMemoryLimit:         &item.Default,
+		krmMaxMemoryLimitRequestRatio: &item.MaxLimitRequestRatio,
+	} {
+		value, ok := cm.Data[key]
+		if !ok {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid functionConfig %s: %w", key, err)
+		}
+		*list = corev1.ResourceList{corev1.ResourceMemory: q}
+	}
+
+	if value, ok := cm.Data[krmDefaultMemoryLimitRequestRatio]; ok {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid functionConfig %s: %w", krmDefaultMemoryLimitRequestRatio, err)
+		}
+		opts = append(opts, mutators.WithDefaultMemoryLimitRequestRatio(ratio))
+	}
+
+	if value, ok := cm.Data[krmEnforcementMode]; ok {
+		mode, err := limitrange.ParseEnforcementMode(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, mutators.WithEnforcementMode(mode))
+	}
+
+	lr := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: cm.Name},
+		Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+	}
+
+	return lr, opts, nil
+}
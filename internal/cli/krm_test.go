@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFunctionConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg       string
+		fc        map[string]interface{}
+		want      *corev1.LimitRangeItem
+		wantOpts  int
+		wantError bool
+	}{
+		{
+			msg: "LimitRange functionConfig is used as is",
+			fc: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "LimitRange",
+				"metadata":   map[string]interface{}{"name": "limits", "namespace": "ignored"},
+				"spec": map[string]interface{}{
+					"limits": []interface{}{
+						map[string]interface{}{"type": "Container", "default": map[string]interface{}{"memory": "1Gi"}},
+					},
+				},
+			},
+			want: &corev1.LimitRangeItem{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		},
+		{
+			msg: "ConfigMap functionConfig",
+			fc: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "hedgetrimmer"},
+				"data": map[string]interface{}{
+					krmDefaultMemoryRequest:           "64Mi",
+					krmMaxMemoryLimitRequestRatio:     "2",
+					krmDefaultMemoryLimitRequestRatio: "1.2",
+					krmEnforcementMode:                "warn",
+				},
+			},
+			want: &corev1.LimitRangeItem{
+				Type:                 corev1.LimitTypeContainer,
+				DefaultRequest:       corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+				MaxLimitRequestRatio: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2")},
+			},
+			wantOpts: 2,
+		},
+		{
+			msg: "Invalid quantity",
+			fc: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "hedgetrimmer"},
+				"data":       map[string]interface{}{krmDefaultMemoryLimit: "lots"},
+			},
+			wantError: true,
+		},
+		{
+			msg:       "Unsupported kind",
+			fc:        map[string]interface{}{"apiVersion": "v1", "kind": "Secret"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		lr, opts, err := functionConfig(&unstructured.Unstructured{Object: test.fc})
+		if test.wantError {
+			assert.Error(t, err, test.msg)
+			continue
+		}
+
+		assert.NoError(t, err, test.msg)
+		assert.Empty(t, lr.Namespace, test.msg)
+		assert.Equal(t, *test.want, lr.Spec.Limits[0], test.msg)
+		assert.Len(t, opts, test.wantOpts, test.msg)
+	}
+}
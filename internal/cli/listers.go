@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/audit"
+)
+
+// listPageSize bounds the objects returned by a single List request of the audit.
+const listPageSize = 500
+
+// getListers returns a lister per enforced resource which lists objects in namespace, or all namespaces if empty.
+func getListers(resources []string, cs kubernetes.Interface, namespace string) ([]audit.Lister, error) {
+	var listers []audit.Lister
+	var unexpected []string
+
+	dedupedResources := make(map[string]bool)
+	for _, resource := range resources {
+		dedupedResources[strings.TrimSpace(resource)] = true
+	}
+
+	for resource := range dedupedResources {
+		switch resource {
+		case cronjobs:
+			listers = append(listers, newLister(batchv1.SchemeGroupVersion.WithKind("CronJob"), cs.BatchV1().CronJobs(namespace).List))
+		case daemonsets:
+			listers = append(listers, newLister(appsv1.SchemeGroupVersion.WithKind("DaemonSet"), cs.AppsV1().DaemonSets(namespace).List))
+		case deployments:
+			listers = append(listers, newLister(appsv1.SchemeGroupVersion.WithKind("Deployment"), cs.AppsV1().Deployments(namespace).List))
+		case jobs:
+			listers = append(listers, newLister(batchv1.SchemeGroupVersion.WithKind("Job"), cs.BatchV1().Jobs(namespace).List))
+		case pods:
+			// pods owned by a controller are reported through their controller
+			listers = append(listers, unowned(newLister(corev1.SchemeGroupVersion.WithKind("Pod"), cs.CoreV1().Pods(namespace).List)))
+		case replicasets:
+			listers = append(listers, newLister(appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), cs.AppsV1().ReplicaSets(namespace).List))
+		case replicationcontrollers:
+			listers = append(listers, newLister(corev1.SchemeGroupVersion.WithKind("ReplicationController"), cs.CoreV1().ReplicationControllers(namespace).List))
+		case statefulsets:
+			listers = append(listers, newLister(appsv1.SchemeGroupVersion.WithKind("StatefulSet"), cs.AppsV1().StatefulSets(namespace).List))
+		default:
+			unexpected = append(unexpected, resource)
+		}
+	}
+
+	if len(unexpected) > 0 {
+		return []audit.Lister{}, fmt.Errorf("unexpected resources: %v", unexpected)
+	}
+
+	return listers, nil
+}
+
+// newLister adapts a typed clientset List function, typed lists do not populate the TypeMeta of their items. Objects
+// are listed in pages of listPageSize.
+func newLister[L runtime.Object](gvk schema.GroupVersionKind, list func(context.Context, metav1.ListOptions) (L, error)) audit.Lister {
+	return audit.ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+		var objects []runtime.Object
+		opts := metav1.ListOptions{Limit: listPageSize}
+
+		for {
+			l, err := list(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+			}
+
+			page, err := meta.ExtractList(l)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, obj := range page {
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+			}
+			objects = append(objects, page...)
+
+			listMeta, err := meta.ListAccessor(l)
+			if err != nil {
+				return nil, err
+			}
+
+			if opts.Continue = listMeta.GetContinue(); opts.Continue == "" {
+				return objects, nil
+			}
+		}
+	})
+}
+
+// unowned filters out the objects with an owner reference.
+func unowned(lister audit.Lister) audit.Lister {
+	return audit.ListerFunc(func(ctx context.Context) ([]runtime.Object, error) {
+		objects, err := lister.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var filtered []runtime.Object
+		for _, obj := range objects {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(accessor.GetOwnerReferences()) == 0 {
+				filtered = append(filtered, obj)
+			}
+		}
+
+		return filtered, nil
+	})
+}
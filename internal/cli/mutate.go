@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/manifest"
+)
+
+type mutateCommand struct {
+	*cobra.Command
+	root *RootCommand
+}
+
+func newMutateCommand(root *RootCommand) *cobra.Command {
+	cmd := mutateCommand{Command: &cobra.Command{}, root: root}
+
+	cmd.Use = "mutate"
+	cmd.Short = "Run manifests through the admission handlers offline"
+	cmd.Long = `Reads Kubernetes manifests from files, directories or stdin and prints them as the webhook would admit them.
+Exits with a non-zero status if any object would be denied.`
+	cmd.SilenceUsage = true
+
+	cmd.Flags().StringSliceP("filename", "f", []string{"-"}, "File, directory or - for stdin containing the manifests to mutate")
+	cmd.Flags().Bool("diff", false, "Print a diff of the mutated objects instead of the manifests")
+	addLimitRangeFlags(cmd.Flags())
+
+	cmd.RunE = cmd.runE
+	return cmd.Command
+}
+
+func (c *mutateCommand) runE(cmd *cobra.Command, args []string) error {
+	objects, err := manifest.Read(viper.GetStringSlice("filename"), cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	ranges, err := limitRangesFromFlags()
+	if err != nil {
+		return err
+	}
+
+	// LimitRanges shipped with the manifests apply as well
+	manifestRanges, err := manifest.LimitRanges(objects)
+	if err != nil {
+		return err
+	}
+
+	evaluator, err := newEvaluator(append(ranges, manifestRanges...), objects, c.root.defaultNamespace())
+	if err != nil {
+		return err
+	}
+
+	mutated, denied, err := evaluateAll(cmd, evaluator, objects, func(result manifest.Result) error {
+		if !viper.GetBool("diff") || !result.Mutates() {
+			return nil
+		}
+
+		diff, err := result.Diff()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprint(cmd.OutOrStdout(), diff)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !viper.GetBool("diff") {
+		if err := manifest.Encode(cmd.OutOrStdout(), mutated); err != nil {
+			return err
+		}
+	}
+
+	if denied > 0 {
+		return fmt.Errorf("%d object(s) would be denied", denied)
+	}
+
+	return nil
+}
+
+// evaluateAll evaluates every object, reporting warnings and denials on stderr, and returns the mutated objects and
+// the number of denied objects. Each result is passed to fn if it is not nil.
+func evaluateAll(cmd *cobra.Command, evaluator *manifest.Evaluator, objects []*unstructured.Unstructured, fn func(manifest.Result) error) ([]*unstructured.Unstructured, int, error) {
+	var mutated []*unstructured.Unstructured
+	denied := 0
+
+	for _, obj := range objects {
+		result, err := evaluator.Evaluate(cmd.Context(), obj)
+		if err != nil {
+			return nil, denied, err
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s: %s\n", result.Name(), warning)
+		}
+
+		if !result.Allowed {
+			denied++
+			fmt.Fprintf(cmd.ErrOrStderr(), "denied: %s: %s\n", result.Name(), result.Message)
+		}
+
+		mutated = append(mutated, result.Mutated)
+
+		if fn != nil {
+			if err := fn(result); err != nil {
+				return nil, denied, err
+			}
+		}
+	}
+
+	return mutated, denied, nil
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutateCommand(t *testing.T) {
+	stdin := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        resources:
+          requests:
+            memory: 1Gi
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: bad
+spec:
+  containers:
+  - name: app
+    resources:
+      requests:
+        memory: 1Gi
+      limits:
+        memory: 5Gi
+`
+
+	stdout := &bytes.Buffer{}
+	cmd := NewRootCommand()
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"mutate", "--log-level=error", "--max-memory-limit-request-ratio=2"})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "1 object(s) would be denied")
+	assert.Contains(t, stdout.String(), "memory: 2Gi")
+	assert.Contains(t, stdout.String(), "memory: 5Gi")
+}
+
+func TestMutateCommandUnconfigured(t *testing.T) {
+	stdin := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: other
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+`
+
+	tests := []struct {
+		args []string
+		err  string
+		msg  string
+	}{
+		{
+			err: "no Container LimitRange applies to namespace(s) default, set --limitrange-file or the memory flags",
+			msg: "Fail without a LimitRange for the namespace of handled objects",
+		},
+		{
+			args: []string{"--deny-unconfigured-namespaces"},
+			err:  "1 object(s) would be denied",
+			msg:  "Deny objects in unconfigured namespaces",
+		},
+		{
+			args: []string{"--default-memory-limit=1Gi"},
+			msg:  "Mutate with the memory flags",
+		},
+	}
+
+	for _, test := range tests {
+		cmd := NewRootCommand()
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"mutate", "--log-level=error"}, test.args...))
+
+		err := cmd.Execute()
+		if test.err == "" {
+			assert.NoError(t, err, test.msg)
+		} else {
+			assert.EqualError(t, err, test.err, test.msg)
+		}
+	}
+}
+
+func TestMutateCommandDefaultLimitRangeMax(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+apiVersion: v1
+kind: LimitRange
+metadata:
+  name: default
+spec:
+  limits:
+  - type: Container
+    max:
+      memory: 1Gi
+`), 0o600))
+
+	stdin := `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big
+spec:
+  containers:
+  - name: app
+    resources:
+      requests:
+        memory: 1Gi
+      limits:
+        memory: 2Gi
+`
+
+	cmd := NewRootCommand()
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&bytes.Buffer{})
+	stderr := &bytes.Buffer{}
+	cmd.SetErr(stderr)
+	cmd.SetArgs([]string{"mutate", "--log-level=error", "--default-limitrange-file=" + file})
+	// the file is removed with the temporary directory
+	t.Cleanup(func() { assert.NoError(t, viper.BindPFlags(NewRootCommand().PersistentFlags())) })
+
+	assert.EqualError(t, cmd.Execute(), "1 object(s) would be denied", "the Max of the default LimitRange is enforced")
+	assert.Contains(t, stderr.String(), "exceeds Max (1Gi)")
+}
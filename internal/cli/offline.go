@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	webhookadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/admission"
+	"github.com/kanopy-platform/hedgetrimmer/internal/manifest"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+)
+
+// newPodTemplateSpecMutator configures the mutator from the root command flags, opts take precedence.
+func newPodTemplateSpecMutator(opts ...mutators.OptionsFunc) (*mutators.PodTemplateSpec, error) {
+	enforcementMode, err := limitrange.ParseEnforcementMode(viper.GetString("enforcement-mode"))
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := messageTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	initPolicy, err := mutators.ParseInitContainerPolicy(viper.GetString("init-container-policy"))
+	if err != nil {
+		return nil, err
+	}
+
+	ptmOpts := []mutators.OptionsFunc{
+		mutators.WithDefaultMemoryLimitRequestRatio(viper.GetFloat64("default-memory-limit-request-ratio")),
+		mutators.WithDryRun(viper.GetBool("dry-run")),
+		mutators.WithEnforcementMode(enforcementMode),
+		mutators.WithMessages(messages),
+		mutators.WithInitContainerPolicy(initPolicy),
+	}
+
+	if ratio := viper.GetFloat64("init-container-memory-limit-request-ratio"); ratio > 0 {
+		ptmOpts = append(ptmOpts, mutators.WithInitContainerMemoryLimitRequestRatio(ratio))
+	}
+
+	return mutators.NewPodTemplateSpec(append(ptmOpts, opts...)...), nil
+}
+
+// messageTemplates returns the messages read from --message-templates, a YAML map of message names to Go templates.
+func messageTemplates() (*mutators.Messages, error) {
+	var templates map[string]string
+
+	if file := viper.GetString("message-templates"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.UnmarshalStrict(b, &templates); err != nil {
+			return nil, fmt.Errorf("failed to read message templates from %s: %w", file, err)
+		}
+	}
+
+	return mutators.NewMessages(templates)
+}
+
+// addLimitRangeFlags adds the flags used to source LimitRanges for commands evaluating manifests outside of a cluster.
+func addLimitRangeFlags(flags *pflag.FlagSet) {
+	flags.StringSlice("limitrange-file", nil, "File or directory containing LimitRange manifests")
+	flags.String("default-memory-request", "", "LimitRange default memory request, used instead of a LimitRange manifest")
+	flags.String("default-memory-limit", "", "LimitRange default memory limit, used instead of a LimitRange manifest")
+	flags.String("max-memory-limit-request-ratio", "", "LimitRange max memory limit/request ratio, used instead of a LimitRange manifest")
+}
+
+// limitRangesFromFlags returns the LimitRanges read from --limitrange-file and the LimitRange described by the
+// memory flags, if any is set.
+func limitRangesFromFlags() ([]*corev1.LimitRange, error) {
+	var ranges []*corev1.LimitRange
+
+	if files := viper.GetStringSlice("limitrange-file"); len(files) > 0 {
+		objects, err := manifest.Read(files, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if ranges, err = manifest.LimitRanges(objects); err != nil {
+			return nil, err
+		}
+	}
+
+	item := corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+	for flag, list := range map[string]*corev1.ResourceList{
+		"default-memory-request":         &item.DefaultRequest,
+		"default-memory-limit":           &item.Default,
+		"max-memory-limit-request-ratio": &item.MaxLimitRequestRatio,
+	} {
+		value := viper.GetString(flag)
+		if value == "" {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+		*list = corev1.ResourceList{corev1.ResourceMemory: q}
+	}
+
+	if item.DefaultRequest != nil || item.Default != nil || item.MaxLimitRequestRatio != nil {
+		ranges = append(ranges, &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "flags"},
+			Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+		})
+	}
+
+	return ranges, nil
+}
+
+// newEvaluator builds an admission router over static LimitRanges. LimitRanges without a namespace apply to every
+// namespace of objects and to defaultNamespace.
+func newEvaluator(ranges []*corev1.LimitRange, objects []*unstructured.Unstructured, defaultNamespace string, opts ...mutators.OptionsFunc) (*manifest.Evaluator, error) {
+	namespaces := map[string]bool{defaultNamespace: true}
+	for _, obj := range objects {
+		if obj.GetNamespace() != "" {
+			namespaces[obj.GetNamespace()] = true
+		}
+	}
+
+	var namespaced []*corev1.LimitRange
+	for _, lr := range ranges {
+		if lr.Namespace != "" {
+			namespaced = append(namespaced, lr)
+			continue
+		}
+
+		for namespace := range namespaces {
+			copy := lr.DeepCopy()
+			copy.Namespace = namespace
+			namespaced = append(namespaced, copy)
+		}
+	}
+
+	limitRanger, err := limitrange.NewStaticLimitRanger(namespaced...)
+	if err != nil {
+		return nil, err
+	}
+
+	ptm, err := newPodTemplateSpecMutator(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers, err := getHandlers(viper.GetStringSlice("resources"), webhookadmission.NewDecoder(scheme), ptm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkConfigured(limitRanger, handlers, objects, defaultNamespace); err != nil {
+		return nil, err
+	}
+
+	unconfiguredOpts, err := unconfiguredNamespaceOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	routerOpts := append([]admission.OptionsFunc{
+		admission.WithAdmissionHandlers(handlers...),
+		admission.WithDocumentationURL(viper.GetString("documentation-url")),
+	}, unconfiguredOpts...)
+
+	router, err := admission.NewRouter(limitRanger, routerOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.NewEvaluator(router, defaultNamespace), nil
+}
+
+// checkConfigured fails if objects handled by handlers are evaluated in namespaces without a Container LimitRange,
+// which would otherwise be admitted unchanged. Namespaces covered by --default-limitrange-file or denied with
+// --deny-unconfigured-namespaces are configured.
+func checkConfigured(limitRanger *limitrange.LimitRange, handlers []admission.AdmissionHandler, objects []*unstructured.Unstructured, defaultNamespace string) error {
+	if viper.GetString("default-limitrange-file") != "" || viper.GetBool("deny-unconfigured-namespaces") {
+		return nil
+	}
+
+	kinds := map[string]bool{}
+	for _, h := range handlers {
+		kinds[h.Kind()] = true
+	}
+
+	var unconfigured []string
+	seen := map[string]bool{}
+	for _, obj := range objects {
+		namespace := obj.GetNamespace()
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+
+		if !kinds[obj.GetKind()] || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+
+		cfg, err := limitRanger.LimitRangeConfig(namespace)
+		if err != nil {
+			return err
+		}
+
+		if cfg == nil {
+			unconfigured = append(unconfigured, namespace)
+		}
+	}
+
+	if len(unconfigured) > 0 {
+		return fmt.Errorf("no Container LimitRange applies to namespace(s) %s, set --limitrange-file or the memory flags", strings.Join(unconfigured, ", "))
+	}
+
+	return nil
+}
+
+// defaultNamespace returns the namespace selected with --namespace, or "default".
+func (c *RootCommand) defaultNamespace() string {
+	if c.k8sFlags.Namespace != nil && *c.k8sFlags.Namespace != "" {
+		return *c.k8sFlags.Namespace
+	}
+
+	return metav1.NamespaceDefault
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/manifest"
+)
+
+type postRenderCommand struct {
+	*cobra.Command
+	root *RootCommand
+}
+
+func newPostRenderCommand(root *RootCommand) *cobra.Command {
+	cmd := postRenderCommand{Command: &cobra.Command{}, root: root}
+
+	cmd.Use = "post-render"
+	cmd.Short = "Run as a Helm post-renderer"
+	cmd.Long = `Reads rendered manifests from stdin and writes them to stdout with memory requests and limits defaulted.
+LimitRanges are read from --limitrange-file, the memory flags, or with --from-cluster from the namespaces of the release.
+
+  helm install app ./chart --namespace app --post-renderer hedgetrimmer \
+    --post-renderer-args post-render --post-renderer-args --from-cluster --post-renderer-args --namespace=app`
+	cmd.SilenceUsage = true
+
+	cmd.Flags().Bool("from-cluster", false, "Read the LimitRanges of the target namespaces from the cluster")
+	addLimitRangeFlags(cmd.Flags())
+
+	cmd.RunE = cmd.runE
+	return cmd.Command
+}
+
+func (c *postRenderCommand) runE(cmd *cobra.Command, args []string) error {
+	objects, err := manifest.Decode(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	ranges, err := limitRangesFromFlags()
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool("from-cluster") {
+		clusterRanges, err := c.clusterLimitRanges(cmd.Context(), objects)
+		if err != nil {
+			return err
+		}
+		ranges = append(ranges, clusterRanges...)
+	}
+
+	evaluator, err := newEvaluator(ranges, objects, c.root.defaultNamespace())
+	if err != nil {
+		return err
+	}
+
+	mutated, denied, err := evaluateAll(cmd, evaluator, objects, nil)
+	if err != nil {
+		return err
+	}
+
+	if denied > 0 {
+		return fmt.Errorf("%d object(s) would be denied", denied)
+	}
+
+	return manifest.Encode(cmd.OutOrStdout(), mutated)
+}
+
+// clusterLimitRanges lists the LimitRanges of the default namespace and every namespace set on objects.
+func (c *postRenderCommand) clusterLimitRanges(ctx context.Context, objects []*unstructured.Unstructured) ([]*corev1.LimitRange, error) {
+	cfg, err := c.root.k8sFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return listLimitRanges(ctx, cs, namespacesOf(objects, c.root.defaultNamespace()))
+}
+
+func namespacesOf(objects []*unstructured.Unstructured, defaultNamespace string) []string {
+	seen := map[string]bool{defaultNamespace: true}
+	namespaces := []string{defaultNamespace}
+
+	for _, obj := range objects {
+		if ns := obj.GetNamespace(); ns != "" && !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	return namespaces
+}
+
+func listLimitRanges(ctx context.Context, cs kubernetes.Interface, namespaces []string) ([]*corev1.LimitRange, error) {
+	var ranges []*corev1.LimitRange
+
+	for _, namespace := range namespaces {
+		list, err := cs.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list LimitRanges in namespace %s: %w", namespace, err)
+		}
+
+		for idx := range list.Items {
+			ranges = append(ranges, &list.Items[idx])
+		}
+	}
+
+	return ranges, nil
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClusterLimitRanges(t *testing.T) {
+	t.Parallel()
+
+	objects := []*unstructured.Unstructured{{}, {}, {}}
+	objects[0].SetNamespace("app")
+	objects[1].SetNamespace("app")
+
+	namespaces := namespacesOf(objects, "release")
+	assert.Equal(t, []string{"release", "app"}, namespaces)
+
+	cs := fake.NewSimpleClientset(
+		&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "app"}},
+		&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "release"}},
+		&corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "other"}},
+	)
+
+	ranges, err := listLimitRanges(context.Background(), cs, namespaces)
+	assert.NoError(t, err)
+	assert.Len(t, ranges, 2)
+}
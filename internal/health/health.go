@@ -0,0 +1,132 @@
+package health
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// InformerSynced fails until the informer completed its initial list.
+func InformerSynced(name string, hasSynced cache.InformerSynced) healthz.Checker {
+	return func(req *http.Request) error {
+		if !hasSynced() {
+			return fmt.Errorf("%s informer has not synced", name)
+		}
+		return nil
+	}
+}
+
+// CertificateValid fails unless the first certificate in the PEM file is currently valid.
+func CertificateValid(path string) healthz.Checker {
+	return func(req *http.Request) error {
+		return checkCertificate(path, time.Now())
+	}
+}
+
+func checkCertificate(path string, now time.Time) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("no certificate found in " + path)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate is not valid before %s", cert.NotBefore)
+	}
+
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", cert.NotAfter)
+	}
+
+	return nil
+}
+
+// InformerWatchdog detects an informer which keeps failing to list and watch. Any progress, an event or a newer
+// resource version, resets the watchdog, so an idle informer is not considered stalled.
+type InformerWatchdog struct {
+	name     string
+	informer cache.SharedIndexInformer
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu              sync.Mutex
+	resourceVersion string
+	failingSince    time.Time
+}
+
+// NewInformerWatchdog must be called before the informer is started.
+func NewInformerWatchdog(name string, informer cache.SharedIndexInformer, timeout time.Duration) (*InformerWatchdog, error) {
+	w := &InformerWatchdog{
+		name:     name,
+		informer: informer,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+
+	if err := informer.SetWatchErrorHandler(w.watchError); err != nil {
+		return nil, err
+	}
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.progress() },
+		UpdateFunc: func(oldObj, newObj interface{}) { w.progress() },
+		DeleteFunc: func(obj interface{}) { w.progress() },
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *InformerWatchdog) watchError(r *cache.Reflector, err error) {
+	cache.DefaultWatchErrorHandler(r, err)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.failingSince.IsZero() {
+		w.failingSince = w.now()
+	}
+}
+
+func (w *InformerWatchdog) progress() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.failingSince = time.Time{}
+}
+
+// Check is a healthz.Checker failing once the informer made no progress for longer than the timeout since its first
+// failure.
+func (w *InformerWatchdog) Check(req *http.Request) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if rv := w.informer.LastSyncResourceVersion(); rv != w.resourceVersion {
+		w.resourceVersion = rv
+		w.failingSince = time.Time{}
+	}
+
+	if !w.failingSince.IsZero() && w.now().Sub(w.failingSince) > w.timeout {
+		return fmt.Errorf("%s informer is failing to watch since %s", w.name, w.failingSince.Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func writeCertificate(t *testing.T, notBefore, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hedgetrimmer"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "tls.crt")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return path
+}
+
+func TestCheckCertificate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		msg     string
+		path    string
+		wantErr bool
+	}{
+		{
+			msg:  "Valid certificate",
+			path: writeCertificate(t, now.Add(-time.Hour), now.Add(time.Hour)),
+		},
+		{
+			msg:     "Expired certificate",
+			path:    writeCertificate(t, now.Add(-2*time.Hour), now.Add(-time.Hour)),
+			wantErr: true,
+		},
+		{
+			msg:     "Certificate not valid yet",
+			path:    writeCertificate(t, now.Add(time.Hour), now.Add(2*time.Hour)),
+			wantErr: true,
+		},
+		{
+			msg:     "Missing certificate",
+			path:    filepath.Join(t.TempDir(), "tls.crt"),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := checkCertificate(test.path, now)
+		assert.Equal(t, test.wantErr, err != nil, test.msg)
+	}
+}
+
+func TestInformerSynced(t *testing.T) {
+	t.Parallel()
+
+	synced := false
+	check := InformerSynced("limitrange", func() bool { return synced })
+
+	assert.Error(t, check(nil))
+	synced = true
+	assert.NoError(t, check(nil))
+}
+
+func TestInformerWatchdog(t *testing.T) {
+	t.Parallel()
+
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	informer := factory.Core().V1().LimitRanges().Informer()
+
+	w, err := NewInformerWatchdog("limitrange", informer, time.Minute)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	w.now = func() time.Time { return now }
+	r := cache.NewReflector(&cache.ListWatch{}, &corev1.LimitRange{}, cache.NewStore(cache.MetaNamespaceKeyFunc), 0)
+
+	assert.NoError(t, w.Check(nil), "an idle informer is healthy")
+
+	w.watchError(r, errors.New("forbidden"))
+	assert.NoError(t, w.Check(nil), "failing within the timeout")
+
+	now = now.Add(2 * time.Minute)
+	assert.Error(t, w.Check(nil), "failing for longer than the timeout")
+
+	w.watchError(r, errors.New("forbidden"))
+	assert.Error(t, w.Check(nil), "repeated failures do not reset the watchdog")
+
+	w.progress()
+	assert.NoError(t, w.Check(nil), "an event resets the watchdog")
+
+	w.watchError(r, errors.New("forbidden"))
+	now = now.Add(2 * time.Minute)
+	assert.Error(t, w.Check(nil))
+}
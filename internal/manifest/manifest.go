@@ -0,0 +1,225 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/pmezard/go-difflib/difflib"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+)
+
+// Decode reads every object of a multi-document YAML or JSON stream, empty documents are skipped.
+func Decode(r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := utilyaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, err
+		}
+
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		objects = append(objects, obj)
+	}
+}
+
+// Encode writes objects as a multi-document YAML stream.
+func Encode(w io.Writer, objects []*unstructured.Unstructured) error {
+	for idx, obj := range objects {
+		b, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+
+		if idx > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Result is the outcome of evaluating a single object.
+type Result struct {
+	// Original is the evaluated object, Mutated is Original with the admission patches applied.
+	Original *unstructured.Unstructured
+	Mutated  *unstructured.Unstructured
+	// Namespace the object was evaluated in.
+	Namespace string
+	// Handled is false for objects of kinds not known to the evaluator, they are always allowed unmodified.
+	Handled  bool
+	Allowed  bool
+	Message  string
+	Warnings []string
+	Patch    []byte
+}
+
+func (r Result) Mutates() bool {
+	return r.Handled && r.Allowed && len(r.Patch) > 0
+}
+
+// Name identifies the object in messages, e.g. Deployment/ns/name.
+func (r Result) Name() string {
+	return fmt.Sprintf("%s/%s/%s", r.Original.GetKind(), r.Namespace, r.Original.GetName())
+}
+
+// Diff returns a unified diff between the original and mutated object in YAML.
+func (r Result) Diff() (string, error) {
+	original, err := yaml.Marshal(r.Original.Object)
+	if err != nil {
+		return "", err
+	}
+
+	mutated, err := yaml.Marshal(r.Mutated.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(original)),
+		B:        difflib.SplitLines(string(mutated)),
+		FromFile: r.Name(),
+		ToFile:   r.Name(),
+		Context:  3,
+	})
+}
+
+// Evaluator runs manifests through an admission handler, typically an admission.Router, as dry-run CREATE requests.
+type Evaluator struct {
+	handler          admission.Handler
+	defaultNamespace string
+	scheme           *runtime.Scheme
+}
+
+func NewEvaluator(handler admission.Handler, defaultNamespace string) *Evaluator {
+	return &Evaluator{
+		handler:          handler,
+		defaultNamespace: defaultNamespace,
+		scheme:           clientgoscheme.Scheme,
+	}
+}
+
+// Evaluate admits a copy of obj. Objects without a namespace are evaluated in the default namespace. The admission
+// patches are computed on the typed representation of the object and applied to obj as a strategic merge patch, so
+// fields added by round tripping through the typed struct do not leak into the result.
+func (e *Evaluator) Evaluate(ctx context.Context, obj *unstructured.Unstructured) (Result, error) {
+	result := Result{Original: obj, Mutated: obj, Namespace: obj.GetNamespace(), Allowed: true}
+	if result.Namespace == "" {
+		result.Namespace = e.defaultNamespace
+	}
+
+	gvk := obj.GroupVersionKind()
+	typed, err := e.scheme.New(gvk)
+	if err != nil {
+		return result, nil
+	}
+	result.Handled = true
+
+	original, err := obj.MarshalJSON()
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(original, typed); err != nil {
+		return result, fmt.Errorf("failed to decode %s: %w", result.Name(), err)
+	}
+
+	normalized, err := json.Marshal(typed)
+	if err != nil {
+		return result, err
+	}
+
+	dryRun := true
+	resp := e.handler.Handle(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       "offline",
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Namespace: result.Namespace,
+		Name:      obj.GetName(),
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: normalized},
+		DryRun:    &dryRun,
+	}})
+
+	result.Allowed = resp.Allowed
+	result.Warnings = resp.Warnings
+	if resp.Result != nil {
+		result.Message = resp.Result.Message
+	}
+
+	if !resp.Allowed || len(resp.Patches) == 0 {
+		return result, nil
+	}
+
+	ops, err := json.Marshal(resp.Patches)
+	if err != nil {
+		return result, err
+	}
+
+	patch, err := jsonpatch.DecodePatch(ops)
+	if err != nil {
+		return result, err
+	}
+
+	mutatedNormalized, err := patch.Apply(normalized)
+	if err != nil {
+		return result, fmt.Errorf("failed to apply patch to %s: %w", result.Name(), err)
+	}
+
+	result.Patch, err = strategicpatch.CreateTwoWayMergePatch(normalized, mutatedNormalized, typed)
+	if err != nil {
+		return result, err
+	}
+
+	mutated, err := strategicpatch.StrategicMergePatch(original, result.Patch, typed)
+	if err != nil {
+		return result, err
+	}
+
+	result.Mutated = &unstructured.Unstructured{}
+	if err := result.Mutated.UnmarshalJSON(mutated); err != nil {
+		return result, err
+	}
+
+	if strings.TrimSpace(string(result.Patch)) == "{}" {
+		result.Patch = nil
+	}
+
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const manifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx
+---
+---
+apiVersion: v1
+kind: LimitRange
+metadata:
+  name: limits
+spec:
+  limits:
+  - type: Container
+    default:
+      memory: 1Gi
+---
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: unknown
+`
+
+// MockHandler sets the memory limit of every container of a Deployment, or denies.
+type MockHandler struct {
+	deny bool
+}
+
+func (m *MockHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if m.deny {
+		return admission.Denied("denied")
+	}
+
+	d := &appsv1.Deployment{}
+	if err := json.Unmarshal(req.Object.Raw, d); err != nil {
+		return admission.Errored(400, err)
+	}
+
+	for idx := range d.Spec.Template.Spec.Containers {
+		d.Spec.Template.Spec.Containers[idx].Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return admission.Errored(400, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, b)
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	objects, err := Decode(strings.NewReader(manifests))
+	assert.NoError(t, err)
+	assert.Len(t, objects, 3)
+
+	ranges, err := LimitRanges(objects)
+	assert.NoError(t, err)
+	assert.Len(t, ranges, 1)
+	assert.Equal(t, "limits", ranges[0].Name)
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(manifests), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte(manifests), 0600))
+
+	objects, err := Read([]string{dir, "-"}, strings.NewReader(manifests))
+	assert.NoError(t, err)
+	assert.Len(t, objects, 6)
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	objects, err := Decode(strings.NewReader(manifests))
+	assert.NoError(t, err)
+
+	e := NewEvaluator(&MockHandler{}, "default")
+
+	result, err := e.Evaluate(context.Background(), objects[0])
+	assert.NoError(t, err)
+	assert.True(t, result.Mutates())
+	assert.Equal(t, "Deployment/default/web", result.Name())
+
+	// only the patched fields are added to the original manifest
+	containers, _, err := unstructured.NestedSlice(result.Mutated.Object, "spec", "template", "spec", "containers")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":      "app",
+		"image":     "nginx",
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "1Gi"}},
+	}, containers[0])
+
+	diff, err := result.Diff()
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "+            memory: 1Gi")
+
+	result, err = e.Evaluate(context.Background(), objects[2])
+	assert.NoError(t, err)
+	assert.False(t, result.Handled)
+	assert.True(t, result.Allowed)
+
+	result, err = NewEvaluator(&MockHandler{deny: true}, "default").Evaluate(context.Background(), objects[0])
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, "denied", result.Message)
+	assert.Equal(t, objects[0], result.Mutated)
+}
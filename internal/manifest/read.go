@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var extensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// Read decodes the objects of every path in order. A path is a file, a directory which is walked for YAML and JSON
+// files, or "-" for stdin.
+func Read(paths []string, stdin io.Reader) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
+	for _, path := range paths {
+		if path == "-" {
+			objs, err := Decode(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read stdin: %w", err)
+			}
+			objects = append(objects, objs...)
+			continue
+		}
+
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// explicitly named files are read regardless of their extension
+			if d.IsDir() || (file != path && !extensions[filepath.Ext(file)]) {
+				return nil
+			}
+
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			objs, err := Decode(f)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", file, err)
+			}
+			objects = append(objects, objs...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// LimitRanges converts every LimitRange in objects.
+func LimitRanges(objects []*unstructured.Unstructured) ([]*corev1.LimitRange, error) {
+	var ranges []*corev1.LimitRange
+
+	for _, obj := range objects {
+		if obj.GroupVersionKind() != corev1.SchemeGroupVersion.WithKind("LimitRange") {
+			continue
+		}
+
+		lr := &corev1.LimitRange{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, lr); err != nil {
+			return nil, fmt.Errorf("failed to decode LimitRange %s: %w", obj.GetName(), err)
+		}
+		ranges = append(ranges, lr)
+	}
+
+	return ranges, nil
+}
@@ -0,0 +1,77 @@
+package manifest
+
+import (
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ResourceListAPIVersion = "config.kubernetes.io/v1"
+	ResourceListKind       = "ResourceList"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ResourceList is the input and output of a KRM function, see
+// https://github.com/kubernetes-sigs/kustomize/blob/master/cmd/config/docs/api-conventions/functions-spec.md
+type ResourceList struct {
+	APIVersion     string                       `json:"apiVersion"`
+	Kind           string                       `json:"kind"`
+	Items          []*unstructured.Unstructured `json:"items"`
+	FunctionConfig *unstructured.Unstructured   `json:"functionConfig,omitempty"`
+	Results        []ResourceListResult         `json:"results,omitempty"`
+}
+
+type ResourceListResult struct {
+	Message     string       `json:"message"`
+	Severity    Severity     `json:"severity,omitempty"`
+	ResourceRef *ResourceRef `json:"resourceRef,omitempty"`
+}
+
+type ResourceRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+func NewResourceRef(obj *unstructured.Unstructured) *ResourceRef {
+	return &ResourceRef{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+	}
+}
+
+func ReadResourceList(r io.Reader) (*ResourceList, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rl := &ResourceList{}
+	if err := yaml.Unmarshal(b, rl); err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+func WriteResourceList(w io.Writer, rl *ResourceList) error {
+	b, err := yaml.Marshal(rl)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
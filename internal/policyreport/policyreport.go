@@ -0,0 +1,491 @@
+package policyreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/audit"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/apis/policyreport/v1alpha2"
+)
+
+const (
+	ReportName = "hedgetrimmer"
+	Source     = "hedgetrimmer"
+	Policy     = "memory-requirements"
+	Category   = "Resources"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+
+	evaluationProperty  = "evaluation"
+	evaluationAdmission = "admission"
+	evaluationAudit     = "audit"
+)
+
+type OptionsFunc func(*Reporter)
+
+func WithFlushInterval(interval time.Duration) OptionsFunc {
+	return func(r *Reporter) {
+		r.flushInterval = interval
+	}
+}
+
+// WithMaxResults bounds the results of a report, so reports of large namespaces stay below the object size limit.
+// Failures and warnings are kept over passing results.
+func WithMaxResults(max int) OptionsFunc {
+	return func(r *Reporter) {
+		r.maxResults = max
+	}
+}
+
+// WithResultTTL drops admission results older than ttl from the reports, 0 keeps them until they are replaced.
+func WithResultTTL(ttl time.Duration) OptionsFunc {
+	return func(r *Reporter) {
+		r.resultTTL = ttl
+	}
+}
+
+// Reporter writes admission and audit results into a wgpolicyk8s.io PolicyReport per namespace, cluster scoped
+// objects are written into a ClusterPolicyReport. Admission results are buffered and merged into the existing reports
+// periodically, audit results replace the previous audit results. Admission results expire after the result TTL, so
+// reports do not keep results of deleted objects.
+type Reporter struct {
+	client        client.Client
+	flushInterval time.Duration
+	resultTTL     time.Duration
+	maxResults    int
+
+	mu      sync.Mutex
+	pending map[string]map[string]v1alpha2.PolicyReportResult
+}
+
+func NewReporter(c client.Client, opts ...OptionsFunc) *Reporter {
+	r := &Reporter{
+		client:        c,
+		flushInterval: 10 * time.Second,
+		resultTTL:     24 * time.Hour,
+		maxResults:    1000,
+		pending:       map[string]map[string]v1alpha2.PolicyReportResult{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+func (r *Reporter) SetupWithManager(m manager.Manager) error {
+	return m.Add(r)
+}
+
+// NeedLeaderElection is false, every replica serves admission requests and flushes its own results.
+func (r *Reporter) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Reporter) Start(ctx context.Context) error {
+	if r.resultTTL > 0 {
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			if err := r.Prune(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "failed to prune policy reports")
+			}
+		}, min(r.resultTTL, time.Hour))
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Flush(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed to flush policy reports")
+		}
+	}, r.flushInterval)
+
+	return nil
+}
+
+// Record buffers the outcome of an admission request, dry-run requests are ignored.
+func (r *Reporter) Record(ctx context.Context, req admission.Request, resp admission.Response) {
+	if req.DryRun != nil && *req.DryRun {
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		// CREATE requests for objects using generateName do not carry a name
+		obj := metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(req.Object.Raw, &obj); err != nil || obj.Name == "" {
+			return
+		}
+		name = obj.Name
+	}
+
+	subject := corev1.ObjectReference{
+		APIVersion: metav1.GroupVersion{Group: req.Kind.Group, Version: req.Kind.Version}.String(),
+		Kind:       req.Kind.Kind,
+		Namespace:  req.Namespace,
+		Name:       name,
+		UID:        req.UID,
+	}
+
+	result := newResult(subject, evaluationAdmission)
+	switch {
+	case !resp.Allowed:
+		result.Result = v1alpha2.StatusFail
+		if resp.Result != nil {
+			result.Description = resp.Result.Message
+		}
+	case len(resp.Warnings) > 0:
+		result.Result = v1alpha2.StatusWarn
+		result.Description = strings.Join(resp.Warnings, "; ")
+	case len(resp.Patches) > 0:
+		result.Result = v1alpha2.StatusPass
+		result.Description = "memory resources defaulted at admission"
+	default:
+		result.Result = v1alpha2.StatusPass
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[req.Namespace]; !ok {
+		r.pending[req.Namespace] = map[string]v1alpha2.PolicyReportResult{}
+	}
+	r.pending[req.Namespace][subjectKey(subject)] = result
+}
+
+// Flush merges buffered admission results into the reports. Results which fail to be written are buffered again.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = map[string]map[string]v1alpha2.PolicyReportResult{}
+	r.mu.Unlock()
+
+	var errs []string
+	for namespace, byKey := range pending {
+		results := make([]v1alpha2.PolicyReportResult, 0, len(byKey))
+		for _, result := range byKey {
+			results = append(results, result)
+		}
+
+		err := r.update(ctx, namespace, func(current []v1alpha2.PolicyReportResult) []v1alpha2.PolicyReportResult {
+			return merge(r.unexpired(current), results)
+		})
+		if err != nil {
+			errs = append(errs, err.Error())
+			r.requeue(namespace, byKey)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (r *Reporter) requeue(namespace string, results map[string]v1alpha2.PolicyReportResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[namespace]; !ok {
+		r.pending[namespace] = map[string]v1alpha2.PolicyReportResult{}
+	}
+
+	for key, result := range results {
+		// newer results recorded since the flush started take precedence
+		if _, ok := r.pending[namespace][key]; !ok {
+			r.pending[namespace][key] = result
+		}
+	}
+}
+
+// HandleReport replaces the audit results of every report, unexpired admission results are kept unless the object
+// was audited since. Reports left without results are deleted.
+func (r *Reporter) HandleReport(ctx context.Context, report audit.Report) error {
+	byNamespace := map[string][]v1alpha2.PolicyReportResult{}
+
+	for _, ar := range report.Results {
+		subject := corev1.ObjectReference{
+			APIVersion: ar.APIVersion,
+			Kind:       ar.Kind,
+			Namespace:  ar.Namespace,
+			Name:       ar.Name,
+		}
+
+		result := newResult(subject, evaluationAudit)
+		result.Description = ar.Message
+		switch ar.Decision {
+		case audit.DecisionDeny:
+			result.Result = v1alpha2.StatusFail
+		case audit.DecisionMutate:
+			result.Result = v1alpha2.StatusWarn
+			if result.Description == "" {
+				result.Description = "memory resources would be defaulted"
+			}
+		default:
+			result.Result = v1alpha2.StatusPass
+		}
+
+		byNamespace[ar.Namespace] = append(byNamespace[ar.Namespace], result)
+	}
+
+	for namespace, results := range byNamespace {
+		if err := r.replaceAudit(ctx, namespace, results); err != nil {
+			return err
+		}
+	}
+
+	// reports of namespaces without audited workloads only keep their admission results
+	reports, err := r.listReports(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range reports {
+		if _, ok := byNamespace[obj.GetNamespace()]; ok {
+			continue
+		}
+
+		if err := r.replaceAudit(ctx, obj.GetNamespace(), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceAudit replaces the audit results of the report of namespace.
+func (r *Reporter) replaceAudit(ctx context.Context, namespace string, results []v1alpha2.PolicyReportResult) error {
+	return r.update(ctx, namespace, func(current []v1alpha2.PolicyReportResult) []v1alpha2.PolicyReportResult {
+		var admitted []v1alpha2.PolicyReportResult
+		for _, result := range r.unexpired(current) {
+			if result.Properties[evaluationProperty] == evaluationAdmission {
+				admitted = append(admitted, result)
+			}
+		}
+
+		// audit results are newer than the admission results of the same object
+		return merge(admitted, results)
+	})
+}
+
+// listReports returns the PolicyReports and the ClusterPolicyReport written by the Reporter.
+func (r *Reporter) listReports(ctx context.Context) ([]client.Object, error) {
+	var reports []client.Object
+
+	namespaced := &v1alpha2.PolicyReportList{}
+	if err := r.client.List(ctx, namespaced, client.MatchingLabels{managedByLabel: Source}); err != nil {
+		return nil, err
+	}
+	for idx := range namespaced.Items {
+		reports = append(reports, &namespaced.Items[idx])
+	}
+
+	cluster := &v1alpha2.ClusterPolicyReportList{}
+	if err := r.client.List(ctx, cluster, client.MatchingLabels{managedByLabel: Source}); err != nil {
+		return nil, err
+	}
+	for idx := range cluster.Items {
+		reports = append(reports, &cluster.Items[idx])
+	}
+
+	return reports, nil
+}
+
+// Prune drops expired admission results from every report and deletes the reports left without results.
+func (r *Reporter) Prune(ctx context.Context) error {
+	if r.resultTTL <= 0 {
+		return nil
+	}
+
+	reports, err := r.listReports(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range reports {
+		if err := r.update(ctx, obj.GetNamespace(), r.unexpired); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unexpired returns the results without the admission results older than the result TTL.
+func (r *Reporter) unexpired(results []v1alpha2.PolicyReportResult) []v1alpha2.PolicyReportResult {
+	if r.resultTTL <= 0 {
+		return results
+	}
+
+	expiry := time.Now().Add(-r.resultTTL).Unix()
+	kept := make([]v1alpha2.PolicyReportResult, 0, len(results))
+	for _, result := range results {
+		if result.Properties[evaluationProperty] == evaluationAdmission && result.Timestamp.Seconds < expiry {
+			continue
+		}
+		kept = append(kept, result)
+	}
+
+	return kept
+}
+
+// update replaces the results of the report of namespace with the results returned by fn, bounded to the maximum
+// number of results. Reports left without results are deleted, unchanged reports are not written.
+func (r *Reporter) update(ctx context.Context, namespace string, fn func([]v1alpha2.PolicyReportResult) []v1alpha2.PolicyReportResult) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, current, summary := newReport(namespace)
+
+		create := false
+		if err := r.client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			create = true
+		}
+
+		results := r.bound(fn(slices.Clone(*current)))
+		sortResults(results)
+
+		switch {
+		case len(results) == 0 && create:
+			return nil
+		case len(results) == 0:
+			return client.IgnoreNotFound(r.client.Delete(ctx, obj))
+		case !create && equality.Semantic.DeepEqual(results, *current):
+			return nil
+		}
+
+		*current = results
+		*summary = summarize(results)
+
+		if create {
+			obj.SetLabels(map[string]string{managedByLabel: Source})
+			return r.client.Create(ctx, obj)
+		}
+
+		return r.client.Update(ctx, obj)
+	})
+}
+
+// bound keeps at most maxResults results, failures and warnings are kept over passing results and newer results over
+// older ones.
+func (r *Reporter) bound(results []v1alpha2.PolicyReportResult) []v1alpha2.PolicyReportResult {
+	if r.maxResults <= 0 || len(results) <= r.maxResults {
+		return results
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if pi, pj := resultPriority(results[i]), resultPriority(results[j]); pi != pj {
+			return pi < pj
+		}
+		return results[i].Timestamp.Seconds > results[j].Timestamp.Seconds
+	})
+
+	return results[:r.maxResults]
+}
+
+func resultPriority(result v1alpha2.PolicyReportResult) int {
+	switch result.Result {
+	case v1alpha2.StatusFail, v1alpha2.StatusError:
+		return 0
+	case v1alpha2.StatusWarn:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func newReport(namespace string) (client.Object, *[]v1alpha2.PolicyReportResult, *v1alpha2.PolicyReportSummary) {
+	if namespace == "" {
+		report := &v1alpha2.ClusterPolicyReport{ObjectMeta: metav1.ObjectMeta{Name: ReportName}}
+		return report, &report.Results, &report.Summary
+	}
+
+	report := &v1alpha2.PolicyReport{ObjectMeta: metav1.ObjectMeta{Name: ReportName, Namespace: namespace}}
+	return report, &report.Results, &report.Summary
+}
+
+func newResult(subject corev1.ObjectReference, evaluation string) v1alpha2.PolicyReportResult {
+	return v1alpha2.PolicyReportResult{
+		Source:     Source,
+		Policy:     Policy,
+		Category:   Category,
+		Severity:   v1alpha2.SeverityMedium,
+		Timestamp:  metav1.Timestamp{Seconds: time.Now().Unix()},
+		Subjects:   []corev1.ObjectReference{subject},
+		Properties: map[string]string{evaluationProperty: evaluation},
+	}
+}
+
+func subjectKey(ref corev1.ObjectReference) string {
+	return fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+}
+
+func resultKey(result v1alpha2.PolicyReportResult) string {
+	if len(result.Subjects) == 0 {
+		return ""
+	}
+	return subjectKey(result.Subjects[0])
+}
+
+// merge replaces the results in current which have the same subject as a result in results.
+func merge(current, results []v1alpha2.PolicyReportResult) []v1alpha2.PolicyReportResult {
+	byKey := map[string]v1alpha2.PolicyReportResult{}
+	for _, result := range current {
+		byKey[resultKey(result)] = result
+	}
+
+	for _, result := range results {
+		byKey[resultKey(result)] = result
+	}
+
+	merged := make([]v1alpha2.PolicyReportResult, 0, len(byKey))
+	for _, result := range byKey {
+		merged = append(merged, result)
+	}
+
+	return merged
+}
+
+func sortResults(results []v1alpha2.PolicyReportResult) {
+	sort.Slice(results, func(i, j int) bool {
+		return resultKey(results[i]) < resultKey(results[j])
+	})
+}
+
+func summarize(results []v1alpha2.PolicyReportResult) v1alpha2.PolicyReportSummary {
+	summary := v1alpha2.PolicyReportSummary{}
+
+	for _, result := range results {
+		switch result.Result {
+		case v1alpha2.StatusPass:
+			summary.Pass++
+		case v1alpha2.StatusFail:
+			summary.Fail++
+		case v1alpha2.StatusWarn:
+			summary.Warn++
+		case v1alpha2.StatusError:
+			summary.Error++
+		case v1alpha2.StatusSkip:
+			summary.Skip++
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,232 @@
+package policyreport
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kanopy-platform/hedgetrimmer/internal/audit"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/apis/policyreport/v1alpha2"
+)
+
+var cfg *rest.Config
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	testenv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd")},
+		ErrorIfCRDPathMissing: true,
+	}
+	if !testing.Short() {
+		var err error
+		cfg, err = testenv.Start()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	res := m.Run()
+
+	if !testing.Short() {
+		if err := testenv.Stop(); err != nil {
+			panic(err)
+		}
+	}
+
+	os.Exit(res)
+}
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha2.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	return scheme
+}
+
+func request(name string, dryRun bool) admission.Request {
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Namespace: "test-ns",
+		Name:      name,
+		DryRun:    &dryRun,
+	}}
+}
+
+func testReporter(t *testing.T, c client.Client, namespace string) {
+	ctx := context.Background()
+	r := NewReporter(c)
+
+	r.Record(ctx, request("denied", false), admission.Denied("memory limit exceeds ratio"))
+	r.Record(ctx, request("mutated", false), admission.PatchResponseFromRaw([]byte(`{}`), []byte(`{"a":"b"}`)))
+	r.Record(ctx, request("simulated", true), admission.Denied("ignored"))
+	assert.NoError(t, r.Flush(ctx))
+
+	report := &v1alpha2.PolicyReport{}
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ReportName}, report))
+	assert.Equal(t, v1alpha2.PolicyReportSummary{Pass: 1, Fail: 1}, report.Summary)
+	assert.Len(t, report.Results, 2)
+
+	// admission results are merged by subject
+	r.Record(ctx, request("denied", false), admission.Allowed(""))
+	assert.NoError(t, r.Flush(ctx))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ReportName}, report))
+	assert.Equal(t, v1alpha2.PolicyReportSummary{Pass: 2}, report.Summary)
+
+	// denials of objects which were never created are kept until they expire
+	r.Record(ctx, request("rejected", false), admission.Denied("memory limit exceeds ratio"))
+	assert.NoError(t, r.Flush(ctx))
+
+	// audit results supersede the admission results of the same object
+	assert.NoError(t, r.HandleReport(ctx, audit.Report{Results: []audit.Result{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "audited", Decision: audit.DecisionMutate},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "mutated", Decision: audit.DecisionCompliant},
+		{APIVersion: "v1", Kind: "Node", Name: "cluster-scoped", Decision: audit.DecisionCompliant},
+	}}))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: ReportName}, &v1alpha2.ClusterPolicyReport{}))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ReportName}, report))
+	assert.Equal(t, v1alpha2.PolicyReportSummary{Pass: 2, Fail: 1, Warn: 1}, report.Summary)
+	assert.Equal(t, map[string]string{
+		"audited":  evaluationAudit,
+		"denied":   evaluationAdmission,
+		"mutated":  evaluationAudit,
+		"rejected": evaluationAdmission,
+	}, evaluations(report))
+
+	// audit results replace the previous audit results
+	assert.NoError(t, r.HandleReport(ctx, audit.Report{Results: []audit.Result{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "audited", Decision: audit.DecisionCompliant},
+	}}))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ReportName}, report))
+	assert.Equal(t, v1alpha2.PolicyReportSummary{Pass: 2, Fail: 1}, report.Summary)
+	assert.NotContains(t, evaluations(report), "mutated")
+
+	// reports left without results are deleted
+	assert.NoError(t, r.HandleReport(ctx, audit.Report{}))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ReportName}, report))
+	assert.Equal(t, v1alpha2.PolicyReportSummary{Pass: 1, Fail: 1}, report.Summary)
+	clusterReports := &v1alpha2.ClusterPolicyReportList{}
+	assert.NoError(t, c.List(ctx, clusterReports))
+	assert.Empty(t, clusterReports.Items)
+}
+
+// evaluations returns the evaluation of the results by subject name.
+func evaluations(report *v1alpha2.PolicyReport) map[string]string {
+	byName := map[string]string{}
+	for _, result := range report.Results {
+		byName[result.Subjects[0].Name] = result.Properties[evaluationProperty]
+	}
+	return byName
+}
+
+func TestReporterMaxResults(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	r := NewReporter(c, WithMaxResults(2))
+
+	r.Record(ctx, request("a-allowed", false), admission.Allowed(""))
+	r.Record(ctx, request("b-denied", false), admission.Denied("memory limit exceeds ratio"))
+	r.Record(ctx, request("c-allowed", false), admission.Allowed(""))
+	assert.NoError(t, r.Flush(ctx))
+
+	report := &v1alpha2.PolicyReport{}
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "test-ns", Name: ReportName}, report))
+	assert.Len(t, report.Results, 2)
+	assert.Equal(t, 1, report.Summary.Fail, "failures are kept over passing results")
+}
+
+func TestReporterResultTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	r := NewReporter(c, WithResultTTL(time.Hour))
+
+	r.Record(ctx, request("old", false), admission.Allowed(""))
+	r.Record(ctx, request("deleted", false), admission.Allowed(""))
+	assert.NoError(t, r.Flush(ctx))
+
+	expire := func(names ...string) {
+		report := &v1alpha2.PolicyReport{}
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "test-ns", Name: ReportName}, report))
+		for idx := range report.Results {
+			if slices.Contains(names, report.Results[idx].Subjects[0].Name) {
+				report.Results[idx].Timestamp.Seconds -= int64((2 * time.Hour).Seconds())
+			}
+		}
+		assert.NoError(t, c.Update(ctx, report))
+	}
+
+	names := func() []string {
+		report := &v1alpha2.PolicyReport{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: "test-ns", Name: ReportName}, report); err != nil {
+			assert.True(t, apierrors.IsNotFound(err))
+			return nil
+		}
+
+		var names []string
+		for _, result := range report.Results {
+			names = append(names, result.Subjects[0].Name)
+		}
+		return names
+	}
+
+	// expired admission results are dropped when merging
+	expire("deleted")
+	r.Record(ctx, request("new", false), admission.Allowed(""))
+	assert.NoError(t, r.Flush(ctx))
+	assert.Equal(t, []string{"new", "old"}, names())
+
+	// and when pruning, reports left without results are deleted
+	expire("old")
+	assert.NoError(t, r.Prune(ctx))
+	assert.Equal(t, []string{"new"}, names())
+
+	expire("new")
+	assert.NoError(t, r.Prune(ctx))
+	assert.Empty(t, names())
+
+	// audit results do not expire
+	assert.NoError(t, r.HandleReport(ctx, audit.Report{Results: []audit.Result{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "test-ns", Name: "audited", Decision: audit.DecisionCompliant},
+	}}))
+	expire("audited")
+	assert.NoError(t, r.Prune(ctx))
+	assert.Equal(t, []string{"audited"}, names())
+}
+
+func TestReporter(t *testing.T) {
+	t.Parallel()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	testReporter(t, c, "test-ns")
+}
+
+func TestIntegrationReporter(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip()
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: newScheme(t)})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Create(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}))
+
+	testReporter(t, c, "test-ns")
+}
@@ -0,0 +1,285 @@
+package webhookconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	DefaultName = "hedgetrimmer"
+	WebhookName = "v1beta1.hedgetrimmer.kanopy-platform.github.io"
+	MutatePath  = "/mutate"
+
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedBy      = "hedgetrimmer"
+)
+
+// kindResources maps the kinds served by the admission handlers to the resources matched by the webhook rules.
+var kindResources = map[string]schema.GroupResource{
+	"CronJob":                 {Group: "batch", Resource: "cronjobs"},
+	"DaemonSet":               {Group: "apps", Resource: "daemonsets"},
+	"Deployment":              {Group: "apps", Resource: "deployments"},
+	"Job":                     {Group: "batch", Resource: "jobs"},
+	"Pod":                     {Group: "", Resource: "pods"},
+	"Pod/ephemeralcontainers": {Group: "", Resource: "pods/ephemeralcontainers"},
+	"Pod/resize":              {Group: "", Resource: "pods/resize"},
+	"ReplicaSet":              {Group: "apps", Resource: "replicasets"},
+	"ReplicationController":   {Group: "", Resource: "replicationcontrollers"},
+	"StatefulSet":             {Group: "apps", Resource: "statefulsets"},
+}
+
+// Rules returns a CREATE and UPDATE rule per API group covering the resources of the given kinds.
+func Rules(kinds []string) ([]admissionregistrationv1.RuleWithOperations, error) {
+	groups := map[string][]string{}
+	for _, kind := range kinds {
+		gr, ok := kindResources[kind]
+		if !ok {
+			return nil, fmt.Errorf("no resource known for kind: %s", kind)
+		}
+		groups[gr.Group] = append(groups[gr.Group], gr.Resource)
+	}
+
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+
+	scope := admissionregistrationv1.NamespacedScope
+	rules := make([]admissionregistrationv1.RuleWithOperations, 0, len(names))
+	for _, group := range names {
+		resources := groups[group]
+		sort.Strings(resources)
+
+		rules = append(rules, admissionregistrationv1.RuleWithOperations{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{group},
+				APIVersions: []string{"*"},
+				Resources:   resources,
+				Scope:       &scope,
+			},
+		})
+	}
+
+	return rules, nil
+}
+
+// CABundleSource provides the PEM encoded CA bundle for the webhook client config. An empty bundle leaves the
+// bundle of the existing configuration untouched, e.g. when it is injected by cert-manager.
+type CABundleSource interface {
+	CABundle(ctx context.Context) ([]byte, error)
+}
+
+// FileCABundle reads the CA bundle from a file, a missing file is an empty bundle.
+type FileCABundle string
+
+func (f FileCABundle) CABundle(ctx context.Context) ([]byte, error) {
+	b, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+type OptionsFunc func(*Reconciler)
+
+func WithName(name string) OptionsFunc {
+	return func(r *Reconciler) {
+		r.name = name
+	}
+}
+
+func WithCABundleSource(s CABundleSource) OptionsFunc {
+	return func(r *Reconciler) {
+		r.caBundle = s
+	}
+}
+
+func WithNamespaceSelector(selector *metav1.LabelSelector) OptionsFunc {
+	return func(r *Reconciler) {
+		r.namespaceSelector = selector
+	}
+}
+
+func WithFailurePolicy(policy admissionregistrationv1.FailurePolicyType) OptionsFunc {
+	return func(r *Reconciler) {
+		r.failurePolicy = policy
+	}
+}
+
+func WithTimeoutSeconds(seconds int32) OptionsFunc {
+	return func(r *Reconciler) {
+		r.timeoutSeconds = seconds
+	}
+}
+
+// Reconciler keeps the MutatingWebhookConfiguration of hedgetrimmer in sync with the registered handlers, it is
+// created at startup and reverted whenever it is edited.
+type Reconciler struct {
+	client            client.Client
+	name              string
+	service           admissionregistrationv1.ServiceReference
+	rules             []admissionregistrationv1.RuleWithOperations
+	caBundle          CABundleSource
+	namespaceSelector *metav1.LabelSelector
+	failurePolicy     admissionregistrationv1.FailurePolicyType
+	timeoutSeconds    int32
+	refresh           chan event.GenericEvent
+}
+
+func NewReconciler(c client.Client, service admissionregistrationv1.ServiceReference, rules []admissionregistrationv1.RuleWithOperations, opts ...OptionsFunc) *Reconciler {
+	r := &Reconciler{
+		client:            c,
+		name:              DefaultName,
+		service:           service,
+		rules:             rules,
+		namespaceSelector: &metav1.LabelSelector{},
+		failurePolicy:     admissionregistrationv1.Ignore,
+		timeoutSeconds:    10,
+		refresh:           make(chan event.GenericEvent, 1),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.service.Path == nil {
+		path := MutatePath
+		r.service.Path = &path
+	}
+
+	return r
+}
+
+func (r *Reconciler) SetupWithManager(m ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(m).
+		Named("webhookconfig").
+		For(&admissionregistrationv1.MutatingWebhookConfiguration{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return o.GetName() == r.name
+		}))).
+		// the configuration may not exist yet, so reconcile once at startup
+		WatchesRawSource(source.Func(func(ctx context.Context, q workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+			q.Add(reconcile.Request{NamespacedName: client.ObjectKey{Name: r.name}})
+			return nil
+		})).
+		WatchesRawSource(source.Channel(r.refresh, &handler.EnqueueRequestForObject{})).
+		Complete(r)
+}
+
+// Refresh queues a reconcile, e.g. after the CA bundle changed.
+func (r *Reconciler) Refresh() {
+	select {
+	case r.refresh <- event.GenericEvent{Object: &admissionregistrationv1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: r.name}}}:
+	default:
+		// a reconcile is already queued
+	}
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if req.Name != r.name {
+		return reconcile.Result{}, nil
+	}
+
+	log := log.FromContext(ctx)
+
+	var caBundle []byte
+	if r.caBundle != nil {
+		var err error
+		if caBundle, err = r.caBundle.CABundle(ctx); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to load CA bundle: %w", err)
+		}
+	}
+
+	current := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	err := r.client.Get(ctx, client.ObjectKey{Name: r.name}, current)
+	if apierrors.IsNotFound(err) {
+		desired := &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   r.name,
+				Labels: map[string]string{managedByLabel: managedBy},
+			},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{r.webhook(caBundle)},
+		}
+
+		log.Info("creating mutating webhook configuration", "name", r.name)
+		return reconcile.Result{}, r.client.Create(ctx, desired)
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if len(caBundle) == 0 {
+		for _, wh := range current.Webhooks {
+			if wh.Name == WebhookName {
+				caBundle = wh.ClientConfig.CABundle
+			}
+		}
+	}
+
+	desired := current.DeepCopy()
+	if desired.Labels == nil {
+		desired.Labels = map[string]string{}
+	}
+	desired.Labels[managedByLabel] = managedBy
+	desired.Webhooks = []admissionregistrationv1.MutatingWebhook{r.webhook(caBundle)}
+
+	if equality.Semantic.DeepEqual(current, desired) {
+		return reconcile.Result{}, nil
+	}
+
+	log.Info("updating mutating webhook configuration", "name", r.name)
+	return reconcile.Result{}, r.client.Update(ctx, desired)
+}
+
+// webhook returns the desired webhook with every field the API server would default set explicitly, so the
+// comparison with the stored configuration is stable.
+func (r *Reconciler) webhook(caBundle []byte) admissionregistrationv1.MutatingWebhook {
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	matchPolicy := admissionregistrationv1.Equivalent
+	reinvocationPolicy := admissionregistrationv1.IfNeededReinvocationPolicy
+	failurePolicy := r.failurePolicy
+	timeoutSeconds := r.timeoutSeconds
+
+	service := r.service.DeepCopy()
+	if service.Port == nil {
+		port := int32(443)
+		service.Port = &port
+	}
+
+	return admissionregistrationv1.MutatingWebhook{
+		Name: WebhookName,
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service:  service,
+			CABundle: caBundle,
+		},
+		Rules:                   r.rules,
+		FailurePolicy:           &failurePolicy,
+		MatchPolicy:             &matchPolicy,
+		NamespaceSelector:       r.namespaceSelector,
+		ObjectSelector:          &metav1.LabelSelector{},
+		SideEffects:             &sideEffects,
+		TimeoutSeconds:          &timeoutSeconds,
+		AdmissionReviewVersions: []string{"v1", "v1beta1"},
+		ReinvocationPolicy:      &reinvocationPolicy,
+	}
+}
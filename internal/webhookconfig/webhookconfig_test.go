@@ -0,0 +1,172 @@
+package webhookconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type staticCABundle []byte
+
+func (s staticCABundle) CABundle(ctx context.Context) ([]byte, error) {
+	return s, nil
+}
+
+func TestRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := Rules([]string{"StatefulSet", "Pod", "Pod/ephemeralcontainers", "Pod/resize", "Deployment", "CronJob"})
+	assert.NoError(t, err)
+	assert.Len(t, rules, 3)
+
+	assert.Equal(t, []string{""}, rules[0].APIGroups)
+	assert.Equal(t, []string{"pods", "pods/ephemeralcontainers", "pods/resize"}, rules[0].Resources)
+	assert.Equal(t, []string{"apps"}, rules[1].APIGroups)
+	assert.Equal(t, []string{"deployments", "statefulsets"}, rules[1].Resources)
+	assert.Equal(t, []string{"batch"}, rules[2].APIGroups)
+	assert.Equal(t, []string{"cronjobs"}, rules[2].Resources)
+	assert.Equal(t, []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}, rules[0].Operations)
+
+	_, err = Rules([]string{"Unknown"})
+	assert.Error(t, err)
+}
+
+func TestFileCABundle(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ca.crt")
+
+	b, err := FileCABundle(path).CABundle(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+
+	assert.NoError(t, os.WriteFile(path, []byte("ca"), 0600))
+	b, err = FileCABundle(path).CABundle(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ca"), b)
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, admissionregistrationv1.AddToScheme(scheme))
+
+	rules, err := Rules([]string{"Deployment"})
+	assert.NoError(t, err)
+
+	service := admissionregistrationv1.ServiceReference{Namespace: "hedgetrimmer", Name: "hedgetrimmer"}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+	req := reconcile.Request{NamespacedName: client.ObjectKey{Name: DefaultName}}
+	ctx := context.Background()
+
+	tests := []struct {
+		msg          string
+		existing     []client.Object
+		caBundle     CABundleSource
+		wantCABundle []byte
+	}{
+		{
+			msg:          "Create a missing configuration",
+			caBundle:     staticCABundle("ca"),
+			wantCABundle: []byte("ca"),
+		},
+		{
+			msg: "Revert edits to the configuration",
+			existing: []client.Object{&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: DefaultName},
+				Webhooks: []admissionregistrationv1.MutatingWebhook{
+					{Name: WebhookName, ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old")}},
+					{Name: "other"},
+				},
+			}},
+			caBundle:     staticCABundle("ca"),
+			wantCABundle: []byte("ca"),
+		},
+		{
+			msg: "Keep an injected CA bundle without a source",
+			existing: []client.Object{&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: DefaultName},
+				Webhooks: []admissionregistrationv1.MutatingWebhook{
+					{Name: WebhookName, ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("injected")}},
+				},
+			}},
+			caBundle:     staticCABundle(nil),
+			wantCABundle: []byte("injected"),
+		},
+	}
+
+	for _, test := range tests {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.existing...).Build()
+		r := NewReconciler(c, service, rules,
+			WithCABundleSource(test.caBundle),
+			WithNamespaceSelector(selector),
+			WithFailurePolicy(admissionregistrationv1.Fail),
+			WithTimeoutSeconds(5),
+		)
+
+		_, err := r.Reconcile(ctx, req)
+		assert.NoError(t, err, test.msg)
+
+		config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: DefaultName}, config), test.msg)
+		assert.Equal(t, managedBy, config.Labels[managedByLabel], test.msg)
+		assert.Len(t, config.Webhooks, 1, test.msg)
+
+		wh := config.Webhooks[0]
+		assert.Equal(t, WebhookName, wh.Name, test.msg)
+		assert.Equal(t, test.wantCABundle, wh.ClientConfig.CABundle, test.msg)
+		assert.Equal(t, MutatePath, *wh.ClientConfig.Service.Path, test.msg)
+		assert.Equal(t, int32(443), *wh.ClientConfig.Service.Port, test.msg)
+		assert.Equal(t, rules, wh.Rules, test.msg)
+		assert.Equal(t, selector, wh.NamespaceSelector, test.msg)
+		assert.Equal(t, admissionregistrationv1.Fail, *wh.FailurePolicy, test.msg)
+		assert.Equal(t, int32(5), *wh.TimeoutSeconds, test.msg)
+
+		// a second reconcile is a no-op
+		_, err = r.Reconcile(ctx, req)
+		assert.NoError(t, err, test.msg)
+
+		unchanged := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: DefaultName}, unchanged), test.msg)
+		assert.Equal(t, config.ResourceVersion, unchanged.ResourceVersion, test.msg)
+	}
+}
+
+func TestReconcileIgnoresOtherConfigurations(t *testing.T) {
+	t.Parallel()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, admissionregistrationv1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	r := NewReconciler(c, admissionregistrationv1.ServiceReference{}, nil)
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKey{Name: "other"}})
+	assert.NoError(t, err)
+
+	list := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	assert.NoError(t, c.List(context.Background(), list))
+	assert.Empty(t, list.Items)
+}
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	r := NewReconciler(nil, admissionregistrationv1.ServiceReference{}, nil)
+	r.Refresh()
+	r.Refresh()
+
+	assert.Len(t, r.refresh, 1)
+	evt := <-r.refresh
+	assert.Equal(t, DefaultName, evt.Object.GetName())
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -10,17 +9,19 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 type CronjobHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewCronjobHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *CronjobHandler {
-	return &CronjobHandler{decoder: decoder, ptm: ptm}
+func NewCronjobHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *CronjobHandler {
+	return &CronjobHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (c *CronjobHandler) Kind() string {
@@ -32,7 +33,7 @@ func (c *CronjobHandler) Handle(ctx context.Context, req kadmission.Request) kad
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(c.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -45,9 +46,9 @@ func (c *CronjobHandler) Handle(ctx context.Context, req kadmission.Request) kad
 
 	pts, err := c.ptm.Mutate(ctx, out.Spec.JobTemplate.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate cronjob %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(c.messages, mutators.MessageMutateFailed, "cronjob", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, jobTemplateSpecPath)
 	}
 
 	out.Spec.JobTemplate.Spec.Template = pts
@@ -16,7 +16,7 @@ func TestCronjobHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewCronjobHandler(decoder, mutator)
+	handler := NewCronjobHandler(decoder, mutator, nil)
 
 	cronjob := &batchv1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
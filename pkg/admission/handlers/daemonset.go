@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -10,17 +9,19 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 type DaemonSetHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewDaemonSetHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *DaemonSetHandler {
-	return &DaemonSetHandler{decoder: decoder, ptm: ptm}
+func NewDaemonSetHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *DaemonSetHandler {
+	return &DaemonSetHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (d *DaemonSetHandler) Kind() string {
@@ -32,7 +33,7 @@ func (d *DaemonSetHandler) Handle(ctx context.Context, req kadmission.Request) k
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(d.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -45,9 +46,9 @@ func (d *DaemonSetHandler) Handle(ctx context.Context, req kadmission.Request) k
 
 	pts, err := d.ptm.Mutate(ctx, out.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate DaemonSet %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(d.messages, mutators.MessageMutateFailed, "DaemonSet", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, templateSpecPath)
 	}
 
 	out.Spec.Template = pts
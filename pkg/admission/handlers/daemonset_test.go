@@ -16,7 +16,7 @@ func TestDaemonSetHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewDaemonSetHandler(decoder, mutator)
+	handler := NewDaemonSetHandler(decoder, mutator, nil)
 
 	d := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2,11 +2,23 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	pkgadmission "github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// Paths of the pod spec in the handled objects.
+var (
+	podSpecPath         = field.NewPath("spec")
+	templateSpecPath    = field.NewPath("spec", "template", "spec")
+	jobTemplateSpecPath = field.NewPath("spec", "jobTemplate", "spec", "template", "spec")
+)
+
 type AllVersionSupporter struct{}
 
 func (s *AllVersionSupporter) VersionSupported(v string) bool {
@@ -21,3 +33,33 @@ func PatchResponse(raw []byte, v interface{}) admission.Response {
 
 	return admission.PatchResponseFromRaw(raw, pjson)
 }
+
+// Denied returns a denial with a StatusCause per violation in err, podSpec is the path of the pod spec in the object.
+func Denied(reason string, err error, podSpec *field.Path) admission.Response {
+	resp := admission.Denied(reason)
+
+	var violations pkgadmission.Violations
+	if errors.As(err, &violations) {
+		resp.Result.Details = &metav1.StatusDetails{Causes: violations.Causes(podSpec)}
+	}
+
+	return resp
+}
+
+// messagesOrDefault returns messages, the default messages if nil.
+func messagesOrDefault(messages *mutators.Messages) *mutators.Messages {
+	if messages == nil {
+		return mutators.DefaultMessages()
+	}
+	return messages
+}
+
+// invalidConfigReason renders the denial for a namespace without LimitRange config.
+func invalidConfigReason(messages *mutators.Messages, namespace string) string {
+	return messages.Render(mutators.MessageInvalidConfig, mutators.MessageData{Namespace: namespace})
+}
+
+// deniedReason renders the denial message of the object wrapping err.
+func deniedReason(messages *mutators.Messages, message, kind, namespace, name string, err error) string {
+	return messages.Render(message, mutators.MessageData{Kind: kind, Namespace: namespace, Name: name, Error: err.Error()})
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"testing"
 
+	pkgadmission "github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 func TestPatchResponse_ErrorsOnNil(t *testing.T) {
@@ -20,3 +24,41 @@ func TestPatchResponse_OK(t *testing.T) {
 	resp := PatchResponse([]byte("{}"), &d)
 	assert.Equal(t, true, resp.Allowed)
 }
+
+func TestDenied(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg        string
+		err        error
+		wantCauses []metav1.StatusCause
+	}{
+		{
+			msg: "Violations become causes with the full field path",
+			err: pkgadmission.Violations{
+				field.Invalid(field.NewPath("containers").Index(1).Child("resources", "limits", "memory"), "2Gi", "memory limit (2Gi) exceeds Max (1Gi)"),
+				field.Required(field.NewPath("initContainers").Index(0).Child("resources", "requests", "memory"), "memory request must be set"),
+			},
+			wantCauses: []metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldValueInvalid, Message: "memory limit (2Gi) exceeds Max (1Gi)", Field: "spec.template.spec.containers[1].resources.limits.memory"},
+				{Type: metav1.CauseTypeFieldValueRequired, Message: "memory request must be set", Field: "spec.template.spec.initContainers[0].resources.requests.memory"},
+			},
+		},
+		{
+			msg: "Other errors have no causes",
+			err: fmt.Errorf("memory resources must be set explicitly"),
+		},
+	}
+
+	for _, test := range tests {
+		resp := Denied("denied", test.err, templateSpecPath)
+		assert.False(t, resp.Allowed, test.msg)
+		assert.Equal(t, "denied", resp.Result.Message, test.msg)
+
+		if test.wantCauses == nil {
+			assert.Nil(t, resp.Result.Details, test.msg)
+			continue
+		}
+		assert.Equal(t, test.wantCauses, resp.Result.Details.Causes, test.msg)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	appsv1 "k8s.io/api/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -14,12 +15,13 @@ import (
 
 type DeploymentHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewDeploymentHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *DeploymentHandler {
-	return &DeploymentHandler{decoder: decoder, ptm: ptm}
+func NewDeploymentHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *DeploymentHandler {
+	return &DeploymentHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (d *DeploymentHandler) Kind() string { return "Deployment" }
@@ -29,7 +31,7 @@ func (d *DeploymentHandler) Handle(ctx context.Context, req kadmission.Request)
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(d.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -43,9 +45,9 @@ func (d *DeploymentHandler) Handle(ctx context.Context, req kadmission.Request)
 
 	pts, err := d.ptm.Mutate(ctx, out.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate deployment %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(d.messages, mutators.MessageMutateFailed, "deployment", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, templateSpecPath)
 	}
 
 	out.Spec.Template = pts
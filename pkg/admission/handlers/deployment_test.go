@@ -16,7 +16,7 @@ func TestDeploymentHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewDeploymentHandler(decoder, &mm)
+	handler := NewDeploymentHandler(decoder, &mm, nil)
 
 	d := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// EphemeralContainersHandler handles the pods/ephemeralcontainers subresource used by kubectl debug. The API does not
+// allow resources on ephemeral containers, they share the memory of the pod, so added containers are only warned
+// about.
+type EphemeralContainersHandler struct {
+	AllVersionSupporter
+	decoder kadmission.Decoder
+}
+
+func NewEphemeralContainersHandler(decoder kadmission.Decoder) *EphemeralContainersHandler {
+	return &EphemeralContainersHandler{decoder: decoder}
+}
+
+func (e *EphemeralContainersHandler) Kind() string {
+	return "Pod"
+}
+
+func (e *EphemeralContainersHandler) SubResource() string {
+	return "ephemeralcontainers"
+}
+
+func (e *EphemeralContainersHandler) Handle(ctx context.Context, req kadmission.Request) kadmission.Response {
+	log := log.FromContext(ctx)
+
+	if req.Operation != admissionv1.Update {
+		return kadmission.Allowed("")
+	}
+
+	pod := corev1.Pod{}
+	if err := e.decoder.Decode(req, &pod); err != nil {
+		log.Error(err, "failed to decode request: %s", req.Name)
+		return kadmission.Errored(http.StatusBadRequest, err)
+	}
+
+	old := corev1.Pod{}
+	if err := e.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+		log.Error(err, "failed to decode old object: %s", req.Name)
+		return kadmission.Errored(http.StatusBadRequest, err)
+	}
+
+	existing := sets.New[string]()
+	for _, c := range old.Spec.EphemeralContainers {
+		existing.Insert(c.Name)
+	}
+
+	for _, c := range pod.Spec.EphemeralContainers {
+		if existing.Has(c.Name) {
+			continue
+		}
+
+		admission.AddWarning(ctx, fmt.Sprintf("ephemeral container %q: memory resources cannot be set, it shares the memory of pod %s and is not bounded by the LimitRange", c.Name, pod.Name))
+	}
+
+	return kadmission.Allowed("")
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	pkgadmission "github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestEphemeralContainersHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := NewEphemeralContainersHandler(admission.NewDecoder(runtime.NewScheme()))
+	assert.Equal(t, "ephemeralcontainers", handler.SubResource())
+
+	debug := func(names ...string) runtime.RawExtension {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+		for _, name := range names {
+			pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name},
+			})
+		}
+		b, err := json.Marshal(pod)
+		assert.NoError(t, err)
+		return runtime.RawExtension{Raw: b}
+	}
+
+	tests := []struct {
+		msg          string
+		operation    admissionv1.Operation
+		object       runtime.RawExtension
+		oldObject    runtime.RawExtension
+		wantWarnings int
+	}{
+		{
+			msg:          "Warn about an added ephemeral container",
+			operation:    admissionv1.Update,
+			object:       debug("debugger-1", "debugger-2"),
+			oldObject:    debug("debugger-1"),
+			wantWarnings: 1,
+		},
+		{
+			msg:       "Existing ephemeral containers are not warned about again",
+			operation: admissionv1.Update,
+			object:    debug("debugger-1"),
+			oldObject: debug("debugger-1"),
+		},
+		{
+			msg:       "Ignore other operations",
+			operation: admissionv1.Create,
+			object:    debug("debugger-1"),
+		},
+	}
+
+	for _, test := range tests {
+		ctx := pkgadmission.WithWarnings(context.Background())
+		resp := handler.Handle(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: test.operation,
+			Object:    test.object,
+			OldObject: test.oldObject,
+		}})
+		assert.True(t, resp.Allowed, test.msg)
+		assert.Len(t, pkgadmission.WarningsFromContext(ctx), test.wantWarnings, test.msg)
+	}
+}
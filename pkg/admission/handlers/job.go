@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -10,17 +9,19 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 type JobHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewJobHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *JobHandler {
-	return &JobHandler{decoder: decoder, ptm: ptm}
+func NewJobHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *JobHandler {
+	return &JobHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (j *JobHandler) Kind() string {
@@ -32,7 +33,7 @@ func (j *JobHandler) Handle(ctx context.Context, req kadmission.Request) kadmiss
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(j.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -45,9 +46,9 @@ func (j *JobHandler) Handle(ctx context.Context, req kadmission.Request) kadmiss
 
 	pts, err := j.ptm.Mutate(ctx, out.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate job %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(j.messages, mutators.MessageMutateFailed, "job", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, templateSpecPath)
 	}
 
 	out.Spec.Template = pts
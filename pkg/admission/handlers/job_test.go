@@ -16,7 +16,7 @@ func TestJobHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewJobHandler(decoder, mutator)
+	handler := NewJobHandler(decoder, mutator, nil)
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
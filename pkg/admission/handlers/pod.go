@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	admissionv1 "k8s.io/api/admission/v1"
@@ -11,17 +10,19 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 type PodHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewPodHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *PodHandler {
-	return &PodHandler{decoder: decoder, ptm: ptm}
+func NewPodHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *PodHandler {
+	return &PodHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (p *PodHandler) Kind() string {
@@ -37,7 +38,7 @@ func (p *PodHandler) Handle(ctx context.Context, req kadmission.Request) kadmiss
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(p.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -55,9 +56,9 @@ func (p *PodHandler) Handle(ctx context.Context, req kadmission.Request) kadmiss
 
 	pts, err := p.ptm.Mutate(ctx, mout, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate pod %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(p.messages, mutators.MessageMutateFailed, "pod", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, podSpecPath)
 	}
 
 	//Pull the mutated spec off of the PTS and replace the Pod.Spec with it
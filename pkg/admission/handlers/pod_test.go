@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	"github.com/stretchr/testify/assert"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -22,7 +23,7 @@ func TestPodHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewPodHandler(decoder, mutator)
+	handler := NewPodHandler(decoder, mutator, nil)
 
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -77,3 +78,34 @@ func TestPodHandler(t *testing.T) {
 		assert.Equal(t, test.mutated, len(resp.Patches) > 0)
 	}
 }
+
+func TestPodHandlerMessageTemplates(t *testing.T) {
+	t.Parallel()
+
+	messages, err := mutators.NewMessages(map[string]string{
+		mutators.MessageMutateFailed:  "{{.Kind}} {{.Name}} rejected: {{.Error}}",
+		mutators.MessageInvalidConfig: "no config in {{.Namespace}}",
+	})
+	assert.NoError(t, err)
+
+	handler := NewPodHandler(admission.NewDecoder(runtime.NewScheme()), mutators.NewPodTemplateSpec(mutators.WithMessages(messages)), messages)
+
+	bytes, err := json.Marshal(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test-ns"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+	})
+	assert.NoError(t, err)
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Namespace: "test-ns",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: bytes},
+	}}
+
+	resp := handler.Handle(limitrange.WithMemoryConfig(context.Background(), &limitrange.Config{}), req)
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, `pod web rejected: container "app": memory request (0) and limit (0) must be set`, resp.Result.Message)
+
+	resp = handler.Handle(context.Background(), req)
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, "no config in test-ns", resp.Result.Message)
+}
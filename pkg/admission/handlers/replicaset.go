@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -10,17 +9,19 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 type ReplicaSetHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewReplicaSetHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *ReplicaSetHandler {
-	return &ReplicaSetHandler{decoder: decoder, ptm: ptm}
+func NewReplicaSetHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *ReplicaSetHandler {
+	return &ReplicaSetHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (r *ReplicaSetHandler) Kind() string {
@@ -32,7 +33,7 @@ func (r *ReplicaSetHandler) Handle(ctx context.Context, req kadmission.Request)
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(r.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -45,9 +46,9 @@ func (r *ReplicaSetHandler) Handle(ctx context.Context, req kadmission.Request)
 
 	pts, err := r.ptm.Mutate(ctx, out.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate ReplicaSet %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(r.messages, mutators.MessageMutateFailed, "ReplicaSet", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, templateSpecPath)
 	}
 
 	out.Spec.Template = pts
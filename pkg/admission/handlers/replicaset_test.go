@@ -16,7 +16,7 @@ func TestReplicaSetHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewReplicaSetHandler(decoder, mutator)
+	handler := NewReplicaSetHandler(decoder, mutator, nil)
 
 	r := &appsv1.ReplicaSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	corev1 "k8s.io/api/core/v1"
@@ -10,17 +9,19 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 type ReplicationControllerHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewReplicationControllerHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *ReplicationControllerHandler {
-	return &ReplicationControllerHandler{decoder: decoder, ptm: ptm}
+func NewReplicationControllerHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *ReplicationControllerHandler {
+	return &ReplicationControllerHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (r *ReplicationControllerHandler) Kind() string {
@@ -32,7 +33,7 @@ func (r *ReplicationControllerHandler) Handle(ctx context.Context, req kadmissio
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(r.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -45,9 +46,9 @@ func (r *ReplicationControllerHandler) Handle(ctx context.Context, req kadmissio
 
 	pts, err := r.ptm.Mutate(ctx, *out.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate ReplicationController %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(r.messages, mutators.MessageMutateFailed, "ReplicationController", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, templateSpecPath)
 	}
 
 	out.Spec.Template = &pts
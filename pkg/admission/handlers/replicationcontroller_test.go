@@ -16,7 +16,7 @@ func TestReplicationControllerHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewReplicationControllerHandler(decoder, mutator)
+	handler := NewReplicationControllerHandler(decoder, mutator, nil)
 
 	rc := &corev1.ReplicationController{
 		ObjectMeta: metav1.ObjectMeta{
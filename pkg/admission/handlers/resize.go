@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// PodResizeHandler validates in-place resizes of running pods through the pods/resize subresource. Resources of a
+// running pod are not defaulted, a resize changing memory resources in violation of the LimitRange is denied. Other
+// resizes are allowed, the memory of pods admitted before enforcement cannot be fixed through a resize.
+type PodResizeHandler struct {
+	AllVersionSupporter
+	decoder   kadmission.Decoder
+	validator admission.PodSpecValidator
+	messages  *mutators.Messages
+}
+
+func NewPodResizeHandler(decoder kadmission.Decoder, validator admission.PodSpecValidator, messages *mutators.Messages) *PodResizeHandler {
+	return &PodResizeHandler{decoder: decoder, validator: validator, messages: messagesOrDefault(messages)}
+}
+
+func (p *PodResizeHandler) Kind() string {
+	return "Pod"
+}
+
+func (p *PodResizeHandler) SubResource() string {
+	return "resize"
+}
+
+func (p *PodResizeHandler) Handle(ctx context.Context, req kadmission.Request) kadmission.Response {
+	log := log.FromContext(ctx)
+
+	if req.Operation != admissionv1.Update {
+		return kadmission.Allowed("")
+	}
+
+	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
+	if err != nil {
+		reason := invalidConfigReason(p.messages, req.Namespace)
+		log.Error(err, reason)
+		return kadmission.Denied(reason)
+	}
+
+	pod := corev1.Pod{}
+	if err := p.decoder.Decode(req, &pod); err != nil {
+		log.Error(err, "failed to decode request: %s", req.Name)
+		return kadmission.Errored(http.StatusBadRequest, err)
+	}
+
+	old := corev1.Pod{}
+	if err := p.decoder.DecodeRaw(req.OldObject, &old); err != nil {
+		log.Error(err, "failed to decode old object: %s", req.Name)
+		return kadmission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !memoryChanged(old.Spec, pod.Spec) {
+		return kadmission.Allowed("memory resources unchanged")
+	}
+
+	if err := p.validator.Validate(ctx, pod.Spec, lrConfig); err != nil {
+		reason := deniedReason(p.messages, mutators.MessageResizeDenied, "pod", pod.Namespace, pod.Name, err)
+		log.Error(err, reason)
+		return Denied(reason, err, podSpecPath)
+	}
+
+	return kadmission.Allowed("")
+}
+
+// memoryChanged reports whether the memory requests or limits of a container or of the pod differ.
+func memoryChanged(old, updated corev1.PodSpec) bool {
+	if !memoryEqual(podResources(old), podResources(updated)) {
+		return true
+	}
+
+	oldContainers := map[string]corev1.ResourceRequirements{}
+	for _, c := range slices.Concat(old.InitContainers, old.Containers) {
+		oldContainers[c.Name] = c.Resources
+	}
+
+	for _, c := range slices.Concat(updated.InitContainers, updated.Containers) {
+		if !memoryEqual(oldContainers[c.Name], c.Resources) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func podResources(spec corev1.PodSpec) corev1.ResourceRequirements {
+	if spec.Resources == nil {
+		return corev1.ResourceRequirements{}
+	}
+	return *spec.Resources
+}
+
+func memoryEqual(a, b corev1.ResourceRequirements) bool {
+	return a.Requests.Memory().Equal(*b.Requests.Memory()) && a.Limits.Memory().Equal(*b.Limits.Memory())
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestPodResizeHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := NewPodResizeHandler(admission.NewDecoder(runtime.NewScheme()), mutators.NewPodTemplateSpec(), nil)
+	assert.Equal(t, "resize", handler.SubResource())
+
+	config := &limitrange.Config{
+		HasMax: true, Max: resource.MustParse("1Gi"), EnforceMinMax: true,
+		HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("2"),
+	}
+
+	pod := func(cpu, memoryRequest, memoryLimit string) runtime.RawExtension {
+		resources := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			Limits:   corev1.ResourceList{},
+		}
+		if memoryRequest != "" {
+			resources.Requests[corev1.ResourceMemory] = resource.MustParse(memoryRequest)
+		}
+		if memoryLimit != "" {
+			resources.Limits[corev1.ResourceMemory] = resource.MustParse(memoryLimit)
+		}
+
+		b, err := json.Marshal(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test-ns"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: resources}}},
+		})
+		assert.NoError(t, err)
+		return runtime.RawExtension{Raw: b}
+	}
+
+	tests := []struct {
+		operation admissionv1.Operation
+		config    *limitrange.Config
+		object    runtime.RawExtension
+		oldObject runtime.RawExtension
+		reject    bool
+		msg       string
+	}{
+		{
+			operation: admissionv1.Update,
+			config:    config,
+			object:    pod("500m", "256Mi", "512Mi"),
+			oldObject: pod("500m", "256Mi", "256Mi"),
+			msg:       "Allow a valid memory resize",
+		},
+		{
+			operation: admissionv1.Update,
+			config:    config,
+			object:    pod("500m", "256Mi", "2Gi"),
+			oldObject: pod("500m", "256Mi", "256Mi"),
+			reject:    true,
+			msg:       "Reject a memory resize above Max",
+		},
+		{
+			operation: admissionv1.Update,
+			config:    config,
+			object:    pod("1", "256Mi", ""),
+			oldObject: pod("500m", "256Mi", ""),
+			msg:       "Allow a CPU resize of a pod without memory limit",
+		},
+		{
+			operation: admissionv1.Update,
+			config:    config,
+			object:    pod("1", "256Mi", "2Gi"),
+			oldObject: pod("500m", "256Mi", "2Gi"),
+			msg:       "Allow a CPU resize of a pod admitted before enforcement",
+		},
+		{
+			operation: admissionv1.Update,
+			object:    pod("500m", "256Mi", "512Mi"),
+			oldObject: pod("500m", "256Mi", "256Mi"),
+			reject:    true,
+			msg:       "Reject without a config",
+		},
+		{
+			operation: admissionv1.Create,
+			config:    config,
+			object:    pod("500m", "256Mi", "2Gi"),
+			msg:       "Ignore other operations",
+		},
+	}
+
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.config != nil {
+			ctx = limitrange.WithMemoryConfig(ctx, test.config)
+		}
+
+		resp := handler.Handle(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: test.operation,
+			Object:    test.object,
+			OldObject: test.oldObject,
+		}})
+		assert.Equal(t, test.reject, !resp.Allowed, test.msg)
+		assert.Empty(t, resp.Patches, test.msg)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/mutators"
 	appsv1 "k8s.io/api/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	kadmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -14,12 +15,13 @@ import (
 
 type StatefulSetHandler struct {
 	AllVersionSupporter
-	decoder kadmission.Decoder
-	ptm     admission.PodTemplateSpecMutator
+	decoder  kadmission.Decoder
+	ptm      admission.PodTemplateSpecMutator
+	messages *mutators.Messages
 }
 
-func NewStatefulSetHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator) *StatefulSetHandler {
-	return &StatefulSetHandler{decoder: decoder, ptm: ptm}
+func NewStatefulSetHandler(decoder kadmission.Decoder, ptm admission.PodTemplateSpecMutator, messages *mutators.Messages) *StatefulSetHandler {
+	return &StatefulSetHandler{decoder: decoder, ptm: ptm, messages: messagesOrDefault(messages)}
 }
 
 func (sts *StatefulSetHandler) Kind() string { return "StatefulSet" }
@@ -29,7 +31,7 @@ func (sts *StatefulSetHandler) Handle(ctx context.Context, req kadmission.Reques
 
 	lrConfig, err := limitrange.MemoryConfigFromContext(ctx)
 	if err != nil {
-		reason := fmt.Sprintf("invalid LimitRange config for namespace: %s", req.Namespace)
+		reason := invalidConfigReason(sts.messages, req.Namespace)
 		log.Error(err, reason)
 		return kadmission.Denied(reason)
 	}
@@ -43,9 +45,9 @@ func (sts *StatefulSetHandler) Handle(ctx context.Context, req kadmission.Reques
 
 	pts, err := sts.ptm.Mutate(ctx, out.Spec.Template, lrConfig)
 	if err != nil {
-		reason := fmt.Sprintf("failed to mutate statefulset %s/%s: %s", out.Namespace, out.Name, err)
+		reason := deniedReason(sts.messages, mutators.MessageMutateFailed, "statefulset", out.Namespace, out.Name, err)
 		log.Error(err, reason)
-		return kadmission.Denied(reason)
+		return Denied(reason, err, templateSpecPath)
 	}
 
 	out.Spec.Template = pts
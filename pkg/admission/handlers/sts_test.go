@@ -16,7 +16,7 @@ func TestStatefulSetHandler(t *testing.T) {
 	scheme := runtime.NewScheme()
 	decoder := admission.NewDecoder(scheme)
 
-	handler := NewStatefulSetHandler(decoder, &mm)
+	handler := NewStatefulSetHandler(decoder, &mm, nil)
 
 	sts := appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -32,6 +32,10 @@ func (mm *MockMutator) Mutate(ctx context.Context, inputs corev1.PodTemplateSpec
 	return mm.spec, mm.err
 }
 
+func (mm *MockMutator) Validate(ctx context.Context, spec corev1.PodSpec, config *limitrange.Config) error {
+	return mm.err
+}
+
 func testHandler(t *testing.T, in runtime.Object, mm *MockMutator, handler admission.Handler) {
 	bytes, err := json.Marshal(in)
 	assert.NoError(t, err)
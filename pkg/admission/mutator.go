@@ -10,3 +10,13 @@ import (
 type PodTemplateSpecMutator interface {
 	Mutate(ctx context.Context, inputPts corev1.PodTemplateSpec, limitRangeMemory *limitrange.Config) (corev1.PodTemplateSpec, error)
 }
+
+// ConfigChecker returns the errors a Config causes for defaulted containers.
+type ConfigChecker interface {
+	CheckConfig(ctx context.Context, limitRangeMemory *limitrange.Config) []error
+}
+
+// PodSpecValidator validates the memory resources of a pod spec without setting missing values.
+type PodSpecValidator interface {
+	Validate(ctx context.Context, spec corev1.PodSpec, limitRangeMemory *limitrange.Config) error
+}
@@ -0,0 +1,16 @@
+package admission
+
+import "context"
+
+type namespaceContextKey struct{}
+
+// WithNamespace returns a context carrying the namespace of the admission request.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceContextKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace of the admission request, or an empty string.
+func NamespaceFromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceContextKey{}).(string)
+	return namespace
+}
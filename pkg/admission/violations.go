@@ -0,0 +1,33 @@
+package admission
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Violations is returned by a PodTemplateSpecMutator for containers which do not comply with the LimitRange, the
+// field paths are relative to the pod spec.
+type Violations field.ErrorList
+
+func (v Violations) Error() string {
+	details := make([]string, 0, len(v))
+	for _, e := range v {
+		details = append(details, e.Detail)
+	}
+	return strings.Join(details, "; ")
+}
+
+// Causes returns a StatusCause per violation, podSpec is the path of the pod spec in the admitted object.
+func (v Violations) Causes(podSpec *field.Path) []metav1.StatusCause {
+	causes := make([]metav1.StatusCause, 0, len(v))
+	for _, e := range v {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseType(e.Type),
+			Message: e.Detail,
+			Field:   podSpec.String() + "." + e.Field,
+		})
+	}
+	return causes
+}
@@ -0,0 +1,43 @@
+package admission
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type warningsContextKey struct{}
+
+type warnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// WithWarnings returns a context that collects admission warnings added through AddWarning.
+func WithWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warningsContextKey{}, &warnings{})
+}
+
+// AddWarning records a warning to be returned with the admission response. It is a no-op if the context does not collect warnings.
+func AddWarning(ctx context.Context, message string) {
+	w, ok := ctx.Value(warningsContextKey{}).(*warnings)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, message)
+}
+
+// WarningsFromContext returns the warnings recorded in the context.
+func WarningsFromContext(ctx context.Context) []string {
+	w, ok := ctx.Value(warningsContextKey{}).(*warnings)
+	if !ok {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.messages)
+}
@@ -0,0 +1,21 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarnings(t *testing.T) {
+	t.Parallel()
+
+	// no collector in context, warnings are dropped
+	AddWarning(context.Background(), "dropped")
+	assert.Empty(t, WarningsFromContext(context.Background()))
+
+	ctx := WithWarnings(context.Background())
+	AddWarning(ctx, "first")
+	AddWarning(ctx, "second")
+	assert.Equal(t, []string{"first", "second"}, WarningsFromContext(ctx))
+}
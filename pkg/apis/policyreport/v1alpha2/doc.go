@@ -0,0 +1,6 @@
+// Package v1alpha2 contains the subset of the wgpolicyk8s.io/v1alpha2 PolicyReport API used by hedgetrimmer.
+// The types are vendored from https://github.com/kubernetes-sigs/wg-policy-prototypes to avoid depending on the
+// prototypes module.
+//
+// +groupName=wgpolicyk8s.io
+package v1alpha2
@@ -0,0 +1,86 @@
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyResult has one of the following values: pass, fail, warn, error, skip.
+type PolicyResult string
+
+const (
+	StatusPass  PolicyResult = "pass"
+	StatusFail  PolicyResult = "fail"
+	StatusWarn  PolicyResult = "warn"
+	StatusError PolicyResult = "error"
+	StatusSkip  PolicyResult = "skip"
+)
+
+// PolicySeverity has one of the following values: critical, high, low, medium, info.
+type PolicySeverity string
+
+const (
+	SeverityCritical PolicySeverity = "critical"
+	SeverityHigh     PolicySeverity = "high"
+	SeverityMedium   PolicySeverity = "medium"
+	SeverityLow      PolicySeverity = "low"
+	SeverityInfo     PolicySeverity = "info"
+)
+
+// PolicyReportSummary provides a status count summary.
+type PolicyReportSummary struct {
+	Pass  int `json:"pass"`
+	Fail  int `json:"fail"`
+	Warn  int `json:"warn"`
+	Error int `json:"error"`
+	Skip  int `json:"skip"`
+}
+
+// PolicyReportResult provides the result for an individual policy.
+type PolicyReportResult struct {
+	Source      string                   `json:"source,omitempty"`
+	Policy      string                   `json:"policy"`
+	Rule        string                   `json:"rule,omitempty"`
+	Category    string                   `json:"category,omitempty"`
+	Severity    PolicySeverity           `json:"severity,omitempty"`
+	Timestamp   metav1.Timestamp         `json:"timestamp,omitempty"`
+	Result      PolicyResult             `json:"result,omitempty"`
+	Scored      bool                     `json:"scored,omitempty"`
+	Subjects    []corev1.ObjectReference `json:"resources,omitempty"`
+	Description string                   `json:"message,omitempty"`
+	Properties  map[string]string        `json:"properties,omitempty"`
+}
+
+// PolicyReport is the Schema for the policyreports API.
+type PolicyReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Scope   *corev1.ObjectReference `json:"scope,omitempty"`
+	Summary PolicyReportSummary     `json:"summary,omitempty"`
+	Results []PolicyReportResult    `json:"results,omitempty"`
+}
+
+// PolicyReportList contains a list of PolicyReport.
+type PolicyReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolicyReport `json:"items"`
+}
+
+// ClusterPolicyReport is the Schema for the clusterpolicyreports API.
+type ClusterPolicyReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Scope   *corev1.ObjectReference `json:"scope,omitempty"`
+	Summary PolicyReportSummary     `json:"summary,omitempty"`
+	Results []PolicyReportResult    `json:"results,omitempty"`
+}
+
+// ClusterPolicyReportList contains a list of ClusterPolicyReport.
+type ClusterPolicyReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPolicyReport `json:"items"`
+}
@@ -0,0 +1,228 @@
+package limitrange
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// cachedConfig is the compiled Config of a namespace, the Config is nil if the namespace has no Container limits.
+// Both are invalidated by the LimitRange events of the namespace.
+type cachedConfig struct {
+	config     *Config
+	err        error
+	generation int64
+	updated    time.Time
+}
+
+// CacheEntry describes the compiled Config of a namespace for debugging.
+type CacheEntry struct {
+	Namespace       string          `json:"namespace"`
+	LimitRange      string          `json:"limitRange,omitempty"`
+	EnforcementMode EnforcementMode `json:"enforcementMode,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Generation      int64           `json:"generation"`
+	Age             string          `json:"age"`
+}
+
+// CacheStatus describes the compiled Configs for debugging.
+type CacheStatus struct {
+	Generation int64        `json:"generation"`
+	Age        string       `json:"age,omitempty"`
+	Namespaces []CacheEntry `json:"namespaces"`
+}
+
+// ResourceEventHandler recompiles the Config of a namespace whenever one of its LimitRanges changes. It must be
+// registered with the informer backing the lister, namespaces without a Config are only cached once it is.
+func (lr *LimitRange) ResourceEventHandler() cache.ResourceEventHandler {
+	lr.mu.Lock()
+	lr.watched = true
+	lr.mu.Unlock()
+
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: lr.onEvent,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// periodic resyncs deliver unchanged objects
+			oldLR, okOld := oldObj.(*corev1.LimitRange)
+			newLR, okNew := newObj.(*corev1.LimitRange)
+			if okOld && okNew && oldLR.ResourceVersion == newLR.ResourceVersion {
+				return
+			}
+			lr.onEvent(newObj)
+		},
+		DeleteFunc: lr.onEvent,
+	}
+}
+
+// NamespaceEventHandler evicts the cached Config of deleted namespaces, namespaces without a Container LimitRange
+// receive no LimitRange event when they are deleted. It must be registered with a Namespace informer.
+func (lr *LimitRange) NamespaceEventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+
+			lr.mu.Lock()
+			defer lr.mu.Unlock()
+			delete(lr.configs, key)
+		},
+	}
+}
+
+func (lr *LimitRange) onEvent(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil || namespace == "" {
+		return
+	}
+
+	lr.refresh(namespace)
+}
+
+// refresh compiles the Config of the namespace from the lister, which is updated before handlers are notified.
+func (lr *LimitRange) refresh(namespace string) {
+	config, err := lr.compile(namespace)
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if lr.configs == nil {
+		lr.configs = map[string]cachedConfig{}
+	}
+
+	lr.generation++
+	lr.updated = time.Now()
+	lr.configs[namespace] = cachedConfig{config: config, err: err, generation: lr.generation, updated: lr.updated}
+}
+
+// cacheUnconfigured caches that the namespace has no Config, unless a LimitRange event was handled since generation
+// was read.
+func (lr *LimitRange) cacheUnconfigured(namespace string, generation int64) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if !lr.watched || lr.generation != generation {
+		return
+	}
+
+	if lr.configs == nil {
+		lr.configs = map[string]cachedConfig{}
+	}
+
+	lr.configs[namespace] = cachedConfig{generation: lr.generation, updated: time.Now()}
+}
+
+// cached returns the compiled Config of the namespace, ok is false if the namespace was not compiled yet.
+func (lr *LimitRange) cached(namespace string) (cachedConfig, bool) {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	c, ok := lr.configs[namespace]
+	return c, ok
+}
+
+func (lr *LimitRange) currentGeneration() int64 {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	return lr.generation
+}
+
+// compile returns the Config of the namespace from the lister.
+func (lr *LimitRange) compile(namespace string) (*Config, error) {
+	ranges, err := lr.lister.LimitRanges(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(ranges)
+}
+
+// compile returns the Config of the first Container limit with the memory Max of the first Pod limit, LimitRanges are
+// ordered by name.
+func compile(ranges []*corev1.LimitRange) (*Config, error) {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Name < ranges[j].Name
+	})
+
+	var config *Config
+	var podMax *resource.Quantity
+
+	for _, r := range ranges {
+		for _, item := range r.Spec.Limits {
+			switch {
+			case item.Type == corev1.LimitTypeContainer && config == nil:
+				c, err := configFor(r, item)
+				if err != nil {
+					return nil, err
+				}
+				config = c
+			case item.Type == corev1.LimitTypePod && podMax == nil:
+				if max, ok := item.Max[corev1.ResourceMemory]; ok {
+					podMax = &max
+				}
+			}
+		}
+	}
+
+	if config != nil && podMax != nil {
+		config.HasPodMax = true
+		config.PodMax = *podMax
+	}
+
+	return config, nil
+}
+
+// Status returns the compiled Configs ordered by namespace.
+func (lr *LimitRange) Status() CacheStatus {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	now := time.Now()
+	status := CacheStatus{Generation: lr.generation, Namespaces: []CacheEntry{}}
+	if !lr.updated.IsZero() {
+		status.Age = now.Sub(lr.updated).Round(time.Second).String()
+	}
+
+	for namespace, c := range lr.configs {
+		entry := CacheEntry{
+			Namespace:  namespace,
+			Generation: c.generation,
+			Age:        now.Sub(c.updated).Round(time.Second).String(),
+		}
+		if c.config != nil {
+			entry.LimitRange = c.config.LimitRangeName
+			entry.EnforcementMode = c.config.EnforcementMode
+		}
+		if c.err != nil {
+			entry.Error = c.err.Error()
+		}
+		status.Namespaces = append(status.Namespaces, entry)
+	}
+
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Namespace < status.Namespaces[j].Namespace
+	})
+
+	return status
+}
+
+// ServeHTTP writes the cache status as JSON.
+func (lr *LimitRange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lr.Status()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
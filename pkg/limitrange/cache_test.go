@@ -0,0 +1,164 @@
+package limitrange
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func containerLimitRange(namespace, name, defaultLimit string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: "1"},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(defaultLimit)},
+			}},
+		},
+	}
+}
+
+func TestResourceEventHandler(t *testing.T) {
+	t.Parallel()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lr := NewLimitRanger(corev1Listers.NewLimitRangeLister(indexer))
+	handler := lr.ResourceEventHandler()
+
+	// the informer updates its store before notifying handlers
+	add := func(obj *corev1.LimitRange) {
+		assert.NoError(t, indexer.Add(obj))
+		handler.OnAdd(obj, false)
+	}
+
+	first := containerLimitRange("a", "b-limits", "1Gi")
+	add(first)
+	add(containerLimitRange("a", "a-limits", "2Gi"))
+
+	config, err := lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a-limits", config.LimitRangeName, "LimitRanges are ordered by name")
+	assert.Equal(t, int64(2), lr.Status().Generation)
+
+	// lookups are served from the compiled Config
+	assert.NoError(t, indexer.Delete(containerLimitRange("a", "a-limits", "2Gi")))
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a-limits", config.LimitRangeName)
+
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "a/a-limits", Obj: containerLimitRange("a", "a-limits", "2Gi")})
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "b-limits", config.LimitRangeName)
+
+	// resyncs do not recompile
+	generation := lr.Status().Generation
+	handler.OnUpdate(first, first)
+	assert.Equal(t, generation, lr.Status().Generation)
+
+	updated := first.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Annotations = map[string]string{EnforcementModeAnnotation: "invalid"}
+	assert.NoError(t, indexer.Update(updated))
+	handler.OnUpdate(first, updated)
+	_, err = lr.LimitRangeConfig("a")
+	assert.Error(t, err)
+
+	assert.NoError(t, indexer.Delete(updated))
+	handler.OnDelete(updated)
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+	status := lr.Status()
+	if assert.Len(t, status.Namespaces, 1) {
+		assert.Equal(t, "a", status.Namespaces[0].Namespace)
+		assert.Empty(t, status.Namespaces[0].LimitRange, "unconfigured namespaces are cached")
+	}
+}
+
+func TestUnconfiguredNamespaceCache(t *testing.T) {
+	t.Parallel()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lr := NewLimitRanger(corev1Listers.NewLimitRangeLister(indexer))
+
+	// without an event handler lookups are compiled from the lister
+	assert.NoError(t, indexer.Add(containerLimitRange("a", "limits", "1Gi")))
+	config, err := lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "limits", config.LimitRangeName)
+	assert.NoError(t, indexer.Delete(containerLimitRange("a", "limits", "1Gi")))
+
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+	assert.Empty(t, lr.Status().Namespaces)
+
+	handler := lr.ResourceEventHandler()
+
+	// unconfigured namespaces are cached until a LimitRange event
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+	assert.Len(t, lr.Status().Namespaces, 1)
+
+	obj := containerLimitRange("a", "limits", "1Gi")
+	assert.NoError(t, indexer.Add(obj))
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Nil(t, config, "lookups are served from the cache")
+
+	handler.OnAdd(obj, false)
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "limits", config.LimitRangeName)
+
+	// deleted namespaces are evicted
+	config, err = lr.LimitRangeConfig("c")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+	_, ok := lr.cached("c")
+	assert.True(t, ok)
+	lr.NamespaceEventHandler().OnDelete(cache.DeletedFinalStateUnknown{Key: "c", Obj: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "c"}}})
+	_, ok = lr.cached("c")
+	assert.False(t, ok)
+
+	// lookups racing an event are not cached
+	generation := lr.currentGeneration()
+	handler.OnAdd(containerLimitRange("b", "limits", "1Gi"), false)
+	lr.cacheUnconfigured("c", generation)
+	_, ok = lr.cached("c")
+	assert.False(t, ok)
+}
+
+func TestCacheStatus(t *testing.T) {
+	t.Parallel()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lr := NewLimitRanger(corev1Listers.NewLimitRangeLister(indexer))
+
+	for _, obj := range []*corev1.LimitRange{containerLimitRange("b", "limits", "1Gi"), containerLimitRange("a", "limits", "1Gi")} {
+		assert.NoError(t, indexer.Add(obj))
+		lr.ResourceEventHandler().OnAdd(obj, true)
+	}
+
+	rec := httptest.NewRecorder()
+	lr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/limitranges", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	status := CacheStatus{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, int64(2), status.Generation)
+	assert.Len(t, status.Namespaces, 2)
+	assert.Equal(t, "a", status.Namespaces[0].Namespace)
+	assert.Equal(t, int64(2), status.Namespaces[0].Generation)
+	assert.Equal(t, "limits", status.Namespaces[1].LimitRange)
+}
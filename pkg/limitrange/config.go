@@ -3,24 +3,68 @@ package limitrange
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/kanopy-platform/hedgetrimmer/pkg/quantity"
+	"gopkg.in/inf.v0"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
-	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
 	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 type LimitRangeContextType string
 
 const LimitRangeContextTypeMemory LimitRangeContextType = "memory"
 
+// EnforcementModeAnnotation can be set on a LimitRange to override the global enforcement mode for its namespace.
+const EnforcementModeAnnotation = "hedgetrimmer.kanopy-platform.github.io/enforcement-mode"
+
+// EnforcementMode controls what happens to the values computed by the mutator.
+type EnforcementMode string
+
+const (
+	// EnforcementModeMutate patches the computed values into the object.
+	EnforcementModeMutate EnforcementMode = "mutate"
+	// EnforcementModeSuggest denies objects that would be mutated and returns the computed values in the denial.
+	EnforcementModeSuggest EnforcementMode = "suggest"
+	// EnforcementModeWarn allows objects unmodified and returns the computed values as warnings.
+	EnforcementModeWarn EnforcementMode = "warn"
+)
+
+func ParseEnforcementMode(mode string) (EnforcementMode, error) {
+	switch m := EnforcementMode(mode); m {
+	case EnforcementModeMutate, EnforcementModeSuggest, EnforcementModeWarn:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown enforcement mode: %q", mode)
+	}
+}
+
 type Config struct {
 	HasDefaultRequest       bool
 	HasDefaultLimit         bool
 	HasMaxLimitRequestRatio bool
+	HasMin                  bool
+	HasMax                  bool
 	DefaultLimit            resource.Quantity
 	DefaultRequest          resource.Quantity
 	MaxLimitRequestRatio    resource.Quantity
+	Min                     resource.Quantity
+	Max                     resource.Quantity
+	// EnforceMinMax denies containers outside of Min and Max and caps computed limits at Max. It is only set for the
+	// cluster default Config, the API server enforces the Min and Max of LimitRanges itself.
+	EnforceMinMax bool
+	// PodMax is the memory Max of a Pod limit, which bounds the effective memory of the whole pod.
+	HasPodMax bool
+	PodMax    resource.Quantity
+	// EnforcementMode overrides the mutator's enforcement mode when set.
+	EnforcementMode EnforcementMode
+	// LimitRangeName is the name of the LimitRange the Config was read from.
+	LimitRangeName string
 }
 
 func NewConfig(lri corev1.LimitRangeItem, resource corev1.ResourceName) Config {
@@ -29,10 +73,63 @@ func NewConfig(lri corev1.LimitRangeItem, resource corev1.ResourceName) Config {
 	l.DefaultRequest, l.HasDefaultRequest = lri.DefaultRequest[resource]
 	l.DefaultLimit, l.HasDefaultLimit = lri.Default[resource]
 	l.MaxLimitRequestRatio, l.HasMaxLimitRequestRatio = lri.MaxLimitRequestRatio[resource]
+	l.Min, l.HasMin = lri.Min[resource]
+	l.Max, l.HasMax = lri.Max[resource]
 
 	return l
 }
 
+// Validate returns an error if the Config can not be satisfied, e.g. the default request exceeds the default limit.
+func (c Config) Validate() error {
+	if c.HasMin && c.HasMax && c.Min.Cmp(c.Max) == 1 {
+		return fmt.Errorf("min (%s) must not exceed max (%s)", c.Min.String(), c.Max.String())
+	}
+
+	if c.HasMaxLimitRequestRatio && c.MaxLimitRequestRatio.Cmp(resource.MustParse("1")) == -1 {
+		return fmt.Errorf("maxLimitRequestRatio (%s) must be at least 1", c.MaxLimitRequestRatio.String())
+	}
+
+	if c.HasDefaultRequest && c.HasDefaultLimit && c.DefaultRequest.Cmp(c.DefaultLimit) == 1 {
+		return fmt.Errorf("default request (%s) must not exceed default limit (%s)", c.DefaultRequest.String(), c.DefaultLimit.String())
+	}
+
+	if c.HasDefaultRequest && c.HasDefaultLimit && c.HasMaxLimitRequestRatio && !c.DefaultRequest.IsZero() {
+		ratio := quantity.Div(c.DefaultLimit, c.DefaultRequest, quantity.ScaleMicro, inf.RoundUp)
+		if ratio.Cmp(c.MaxLimitRequestRatio) == 1 {
+			return fmt.Errorf("default limit (%s) to default request (%s) ratio (%s) exceeds maxLimitRequestRatio (%s)",
+				c.DefaultLimit.String(), c.DefaultRequest.String(), ratio.String(), c.MaxLimitRequestRatio.String())
+		}
+	}
+
+	for _, d := range []struct {
+		name  string
+		has   bool
+		value resource.Quantity
+	}{
+		{"default request", c.HasDefaultRequest, c.DefaultRequest},
+		{"default limit", c.HasDefaultLimit, c.DefaultLimit},
+	} {
+		if !d.has {
+			continue
+		}
+
+		if c.HasMin && d.value.Cmp(c.Min) == -1 {
+			return fmt.Errorf("%s (%s) must not be less than min (%s)", d.name, d.value.String(), c.Min.String())
+		}
+
+		if c.HasMax && d.value.Cmp(c.Max) == 1 {
+			return fmt.Errorf("%s (%s) must not exceed max (%s)", d.name, d.value.String(), c.Max.String())
+		}
+	}
+
+	return nil
+}
+
+// CompileConfig returns the memory Config of the first Container limit of the LimitRanges ordered by name, or nil.
+func CompileConfig(ranges ...*corev1.LimitRange) (*Config, error) {
+	return compile(ranges)
+}
+
 func MemoryConfigFromContext(ctx context.Context) (*Config, error) {
 	return configFromContext(ctx, LimitRangeContextTypeMemory)
 }
@@ -53,11 +150,47 @@ func configFromContext(ctx context.Context, key LimitRangeContextType) (*Config,
 // LimitRange provides an implementation of the LimitRanger interface defined in admission. This implementation is designed to provider a generic config sourcing tool for all mutation handlers.
 type LimitRange struct {
 	lister corev1Listers.LimitRangeLister
+
+	client             kubernetes.Interface
+	limiter            flowcontrol.RateLimiter
+	inflight           chan struct{}
+	liveTimeout        time.Duration
+	hasSynced          cache.InformerSynced
+	namespaces         corev1Listers.NamespaceLister
+	newNamespaceWindow time.Duration
+
+	mu         sync.RWMutex
+	watched    bool
+	configs    map[string]cachedConfig
+	generation int64
+	updated    time.Time
 }
 
 // NewLimitRanger take a limitrangelister and returns a pointer to a configured LimitRange. This satisfies the LimitRanger interface
-func NewLimitRanger(lister corev1Listers.LimitRangeLister) *LimitRange {
-	return &LimitRange{lister: lister}
+func NewLimitRanger(lister corev1Listers.LimitRangeLister, opts ...OptionsFunc) *LimitRange {
+	lr := &LimitRange{
+		lister:      lister,
+		inflight:    make(chan struct{}, 4),
+		liveTimeout: 2 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(lr)
+	}
+
+	return lr
+}
+
+// NewStaticLimitRanger returns a LimitRange serving the given LimitRanges, for evaluating objects outside of a cluster.
+func NewStaticLimitRanger(ranges ...*corev1.LimitRange) (*LimitRange, error) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, lr := range ranges {
+		if err := indexer.Add(lr); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewLimitRanger(corev1Listers.NewLimitRangeLister(indexer)), nil
 }
 
 // LimitRangeConfig takes a namespace string and returns a Config for memory or a nil if no limit range of type Container is found in the namespace. It returns a non-nil error if there is an error sourcing data from the cluster api or the namespace name is empty
@@ -65,18 +198,36 @@ func (lr *LimitRange) LimitRangeConfig(namespace string) (*Config, error) {
 	if namespace == "" {
 		return nil, fmt.Errorf("invalid namespace: %q", namespace)
 	}
-	ranges, err := lr.lister.LimitRanges(namespace).List(labels.Everything())
-	if err != nil {
-		return nil, err
+
+	if c, ok := lr.cached(namespace); ok {
+		return c.config, c.err
+	}
+
+	// namespaces without a compiled Config, or a lister without an event handler
+	generation := lr.currentGeneration()
+	config, err := lr.compile(namespace)
+	if config != nil || err != nil {
+		return config, err
 	}
 
-	for _, lr := range ranges {
-		for _, item := range lr.Spec.Limits {
-			if item.Type == corev1.LimitTypeContainer {
-				config := NewConfig(item, corev1.ResourceMemory)
-				return &config, nil
-			}
+	if lr.client != nil {
+		if reason := lr.fallbackReason(namespace); reason != "" {
+			return lr.liveConfig(namespace, reason)
 		}
 	}
+
+	lr.cacheUnconfigured(namespace, generation)
 	return nil, nil
 }
+
+func configFor(lr *corev1.LimitRange, item corev1.LimitRangeItem) (*Config, error) {
+	config := NewConfig(item, corev1.ResourceMemory)
+	config.LimitRangeName = lr.Name
+	if mode, ok := lr.Annotations[EnforcementModeAnnotation]; ok {
+		var err error
+		if config.EnforcementMode, err = ParseEnforcementMode(mode); err != nil {
+			return nil, fmt.Errorf("limitrange %s/%s: %w", lr.Namespace, lr.Name, err)
+		}
+	}
+	return &config, nil
+}
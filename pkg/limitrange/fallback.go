@@ -0,0 +1,98 @@
+package limitrange
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+type OptionsFunc func(*LimitRange)
+
+// WithLiveFallback looks up LimitRanges through the API server when the cache has no Config for a namespace and the
+// cache is not synced or the namespace was created recently. Lookups are rate limited, bounded in concurrency and
+// time, and fail open.
+func WithLiveFallback(client kubernetes.Interface, qps float32, burst int) OptionsFunc {
+	return func(lr *LimitRange) {
+		lr.client = client
+		lr.limiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+}
+
+// WithHasSynced reports whether the cache backing the lister is synced.
+func WithHasSynced(hasSynced cache.InformerSynced) OptionsFunc {
+	return func(lr *LimitRange) {
+		lr.hasSynced = hasSynced
+	}
+}
+
+// WithNamespaceLister treats namespaces created within window, or not yet known to the lister, as recently created.
+func WithNamespaceLister(lister corev1Listers.NamespaceLister, window time.Duration) OptionsFunc {
+	return func(lr *LimitRange) {
+		lr.namespaces = lister
+		lr.newNamespaceWindow = window
+	}
+}
+
+// fallbackReason returns why the cache can not be trusted to have the LimitRanges of the namespace, if at all.
+func (lr *LimitRange) fallbackReason(namespace string) string {
+	if lr.hasSynced != nil && !lr.hasSynced() {
+		return fallbackReasonUnsynced
+	}
+
+	if lr.namespaces == nil {
+		return ""
+	}
+
+	ns, err := lr.namespaces.Get(namespace)
+	if err != nil || time.Since(ns.CreationTimestamp.Time) < lr.newNamespaceWindow {
+		return fallbackReasonNewNamespace
+	}
+
+	return ""
+}
+
+// liveConfig compiles the Config of the namespace from the API server. Lookups which are rate limited, exceed the
+// concurrency or fail return no Config, like the cache would.
+func (lr *LimitRange) liveConfig(namespace, reason string) (*Config, error) {
+	if !lr.limiter.TryAccept() {
+		fallbackRequests.WithLabelValues(reason, fallbackResultRateLimited).Inc()
+		return nil, nil
+	}
+
+	select {
+	case lr.inflight <- struct{}{}:
+		defer func() { <-lr.inflight }()
+	default:
+		fallbackRequests.WithLabelValues(reason, fallbackResultRateLimited).Inc()
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lr.liveTimeout)
+	defer cancel()
+
+	list, err := lr.client.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		fallbackRequests.WithLabelValues(reason, fallbackResultError).Inc()
+		return nil, nil
+	}
+
+	ranges := make([]*corev1.LimitRange, 0, len(list.Items))
+	for i := range list.Items {
+		ranges = append(ranges, &list.Items[i])
+	}
+
+	config, err := compile(ranges)
+	if config == nil && err == nil {
+		fallbackRequests.WithLabelValues(reason, fallbackResultNotFound).Inc()
+	} else {
+		fallbackRequests.WithLabelValues(reason, fallbackResultFound).Inc()
+	}
+
+	return config, err
+}
@@ -0,0 +1,107 @@
+package limitrange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestLiveFallback(t *testing.T) {
+	t.Parallel()
+
+	cs := fake.NewSimpleClientset()
+	for _, ns := range []string{"new", "old", "unknown"} {
+		_, err := cs.CoreV1().LimitRanges(ns).Create(context.Background(), containerLimitRange(ns, "limits", "1Gi"), metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NoError(t, namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.Now()}}))
+	assert.NoError(t, namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))}}))
+
+	// the cache has not seen any LimitRange yet
+	empty := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	tests := []struct {
+		msg       string
+		namespace string
+		synced    bool
+		burst     int
+		wantFound bool
+	}{
+		{
+			msg:       "Unsynced cache",
+			namespace: "old",
+			burst:     1,
+			wantFound: true,
+		},
+		{
+			msg:       "Synced cache and old namespace",
+			namespace: "old",
+			synced:    true,
+			burst:     1,
+		},
+		{
+			msg:       "Recently created namespace",
+			namespace: "new",
+			synced:    true,
+			burst:     1,
+			wantFound: true,
+		},
+		{
+			msg:       "Namespace unknown to the cache",
+			namespace: "unknown",
+			synced:    true,
+			burst:     1,
+			wantFound: true,
+		},
+		{
+			msg:       "Rate limited",
+			namespace: "new",
+			synced:    true,
+		},
+	}
+
+	for _, test := range tests {
+		synced := test.synced
+		lr := NewLimitRanger(corev1Listers.NewLimitRangeLister(empty),
+			WithLiveFallback(cs, 0.001, test.burst),
+			WithHasSynced(func() bool { return synced }),
+			WithNamespaceLister(corev1Listers.NewNamespaceLister(namespaces), time.Minute),
+		)
+
+		config, err := lr.LimitRangeConfig(test.namespace)
+		assert.NoError(t, err, test.msg)
+		assert.Equal(t, test.wantFound, config != nil, test.msg)
+	}
+}
+
+func TestLiveFallbackConcurrency(t *testing.T) {
+	t.Parallel()
+
+	lr := NewLimitRanger(corev1Listers.NewLimitRangeLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		WithLiveFallback(fake.NewSimpleClientset(containerLimitRange("a", "limits", "1Gi")), 100, 100),
+		WithHasSynced(func() bool { return false }),
+	)
+
+	// every slot is taken by lookups in flight
+	for i := 0; i < cap(lr.inflight); i++ {
+		lr.inflight <- struct{}{}
+	}
+
+	config, err := lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+
+	<-lr.inflight
+	config, err = lr.LimitRangeConfig("a")
+	assert.NoError(t, err)
+	assert.NotNil(t, config)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	corev1Listers "k8s.io/client-go/listers/core/v1"
 )
@@ -159,3 +160,166 @@ func TestLimitRangeConfig(t *testing.T) {
 		assert.Equal(t, test.want, NewConfig(test.limitRange, test.resource), test.msg)
 	}
 }
+
+func TestLimitRangerEnforcementModeAnnotation(t *testing.T) {
+	t.Parallel()
+
+	newLimitRange := func(mode string) *LimitRange {
+		return &LimitRange{
+			lister: &MockLimitRanger{
+				nl: &MockLimitRangeNamespaceLister{
+					ranges: []*corev1.LimitRange{
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Annotations: map[string]string{EnforcementModeAnnotation: mode},
+							},
+							Spec: corev1.LimitRangeSpec{
+								Limits: []corev1.LimitRangeItem{{Type: corev1.LimitTypeContainer}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	c, err := newLimitRange("warn").LimitRangeConfig("t")
+	assert.NoError(t, err)
+	assert.Equal(t, EnforcementModeWarn, c.EnforcementMode)
+
+	_, err = newLimitRange("invalid").LimitRangeConfig("t")
+	assert.Error(t, err)
+}
+
+func TestNewStaticLimitRanger(t *testing.T) {
+	t.Parallel()
+
+	lr, err := NewStaticLimitRanger(&corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "t"},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:    corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	c, err := lr.LimitRangeConfig("t")
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{HasDefaultLimit: true, DefaultLimit: resource.MustParse("1Gi"), LimitRangeName: "limits"}, c)
+
+	c, err = lr.LimitRangeConfig("other")
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestCompileConfigPodMax(t *testing.T) {
+	t.Parallel()
+
+	memory := func(q string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(q)}
+	}
+
+	c, err := CompileConfig(
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "pods"},
+			Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{Type: corev1.LimitTypePod, Max: memory("4Gi")}}},
+		},
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "containers"},
+			Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{Type: corev1.LimitTypeContainer, Max: memory("1Gi")}}},
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{
+		HasMax: true, Max: resource.MustParse("1Gi"),
+		HasPodMax: true, PodMax: resource.MustParse("4Gi"),
+		LimitRangeName: "containers",
+	}, c)
+
+	c, err = CompileConfig(&corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "pods"},
+		Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{Type: corev1.LimitTypePod, Max: memory("4Gi")}}},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, c, "a Pod limit alone does not configure the namespace")
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg       string
+		config    Config
+		wantError bool
+	}{
+		{
+			msg: "Consistent config",
+			config: Config{
+				HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi"),
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("128Mi"),
+				HasMin: true, Min: resource.MustParse("32Mi"),
+				HasMax: true, Max: resource.MustParse("1Gi"),
+				HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("2"),
+			},
+		},
+		{
+			msg:       "Min exceeds Max",
+			config:    Config{HasMin: true, Min: resource.MustParse("2Gi"), HasMax: true, Max: resource.MustParse("1Gi")},
+			wantError: true,
+		},
+		{
+			msg:       "Ratio below 1",
+			config:    Config{HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("0.5")},
+			wantError: true,
+		},
+		{
+			msg:       "Default request exceeds default limit",
+			config:    Config{HasDefaultRequest: true, DefaultRequest: resource.MustParse("2Gi"), HasDefaultLimit: true, DefaultLimit: resource.MustParse("1Gi")},
+			wantError: true,
+		},
+		{
+			msg:       "Default limit exceeds Max",
+			config:    Config{HasDefaultLimit: true, DefaultLimit: resource.MustParse("2Gi"), HasMax: true, Max: resource.MustParse("1Gi")},
+			wantError: true,
+		},
+		{
+			msg:       "Default request less than Min",
+			config:    Config{HasDefaultRequest: true, DefaultRequest: resource.MustParse("16Mi"), HasMin: true, Min: resource.MustParse("32Mi")},
+			wantError: true,
+		},
+		{
+			msg: "Defaults exceed the ratio",
+			config: Config{
+				HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi"),
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("256Mi"),
+				HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("2"),
+			},
+			wantError: true,
+		},
+		{
+			msg: "Defaults at the ratio",
+			config: Config{
+				HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi"),
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("128Mi"),
+				HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("2"),
+			},
+		},
+		{
+			msg: "Defaults ratio rounded up like the mutator's",
+			config: Config{
+				HasDefaultRequest: true, DefaultRequest: resource.MustParse("3Mi"),
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("4Mi"),
+				HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("1.333333"),
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.wantError, test.config.Validate() != nil, test.msg)
+	}
+}
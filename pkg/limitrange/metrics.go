@@ -0,0 +1,25 @@
+package limitrange
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	fallbackReasonUnsynced     = "unsynced"
+	fallbackReasonNewNamespace = "new_namespace"
+
+	fallbackResultFound       = "found"
+	fallbackResultNotFound    = "not_found"
+	fallbackResultError       = "error"
+	fallbackResultRateLimited = "rate_limited"
+)
+
+var fallbackRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "hedgetrimmer_limitrange_fallback_requests_total",
+	Help: "Number of LimitRange lookups served by the API server instead of the cache",
+}, []string{"reason", "result"})
+
+func init() {
+	metrics.Registry.MustRegister(fallbackRequests)
+}
@@ -0,0 +1,88 @@
+package mutators
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// CheckConfig returns the errors the mutator would return for containers defaulted from the Config, which catches
+// LimitRanges whose defaults are rejected once combined with the default ratio and BinarySI rounding. Containers
+// are defaulted by the API server's LimitRanger first, the way they reach the webhook.
+func (p *PodTemplateSpec) CheckConfig(ctx context.Context, cfg *limitrange.Config) []error {
+	type probe struct {
+		name    string
+		request *resource.Quantity
+	}
+
+	var probes []probe
+	if cfg.HasDefaultRequest || cfg.HasDefaultLimit {
+		probes = append(probes, probe{name: "default"})
+	}
+
+	// the limit of a container with only a request is computed by the mutator unless there is a default limit
+	if !cfg.HasDefaultLimit {
+		if cfg.HasMin && !cfg.Min.IsZero() {
+			probes = append(probes, probe{name: "min-request", request: &cfg.Min})
+		}
+
+		if cfg.HasMax && !cfg.Max.IsZero() {
+			probes = append(probes, probe{name: "max-request", request: &cfg.Max})
+		}
+	}
+
+	// the API server enforces the Min and Max of the LimitRange on the defaulted containers
+	enforced := *cfg
+	enforced.EnforceMinMax = true
+	cfg = &enforced
+
+	var errs []error
+	for _, probe := range probes {
+		container := corev1.Container{Name: probe.name}
+		if probe.request != nil {
+			container.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: probe.request.DeepCopy()}
+		}
+
+		applyLimitRangeDefaults(&container, cfg)
+		p.setMemoryRequest(ctx, &container, cfg)
+		p.setMemoryLimit(ctx, &container, cfg)
+
+		for _, violation := range p.validateMemoryRequirements(ctx, container, field.NewPath("containers").Index(0), podMemory{}, cfg) {
+			errs = append(errs, errors.New(violation.Detail))
+		}
+	}
+
+	return errs
+}
+
+// applyLimitRangeDefaults sets the memory defaults the way the API server's LimitRanger does, where the default
+// request falls back to the default limit.
+func applyLimitRangeDefaults(container *corev1.Container, cfg *limitrange.Config) {
+	if _, ok := container.Resources.Limits[corev1.ResourceMemory]; !ok && cfg.HasDefaultLimit {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceMemory] = cfg.DefaultLimit.DeepCopy()
+	}
+
+	if _, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+		return
+	}
+
+	request := cfg.DefaultRequest
+	if !cfg.HasDefaultRequest {
+		if !cfg.HasDefaultLimit {
+			return
+		}
+		request = cfg.DefaultLimit
+	}
+
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	container.Resources.Requests[corev1.ResourceMemory] = request.DeepCopy()
+}
@@ -0,0 +1,64 @@
+package mutators
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestCheckConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg      string
+		ratio    float64
+		config   *limitrange.Config
+		wantErrs int
+	}{
+		{
+			msg:   "Consistent defaults",
+			ratio: 1.1,
+			config: &limitrange.Config{
+				HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi"),
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("128Mi"),
+				HasMin: true, Min: resource.MustParse("32Mi"),
+				HasMax: true, Max: resource.MustParse("1Gi"),
+			},
+		},
+		{
+			msg:   "Defaulted limit exceeds the ratio",
+			ratio: 1.1,
+			config: &limitrange.Config{
+				HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi"),
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("256Mi"),
+				HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("2"),
+			},
+			wantErrs: 1,
+		},
+		{
+			msg:   "BinarySI rounding drops the computed limit below the Min request",
+			ratio: 1.0001,
+			config: &limitrange.Config{
+				HasMin: true, Min: resource.MustParse("1M"),
+			},
+			wantErrs: 1,
+		},
+		{
+			msg:   "Default limit below the Min request",
+			ratio: 1.1,
+			config: &limitrange.Config{
+				HasDefaultLimit: true, DefaultLimit: resource.MustParse("64Mi"),
+				HasMin: true, Min: resource.MustParse("128Mi"),
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, test := range tests {
+		pts := NewPodTemplateSpec(WithDefaultMemoryLimitRequestRatio(test.ratio))
+		assert.Len(t, pts.CheckConfig(context.Background(), test.config), test.wantErrs, test.msg)
+	}
+}
@@ -1,7 +1,7 @@
 package mutators
 
-import "gopkg.in/inf.v0"
+import "github.com/kanopy-platform/hedgetrimmer/pkg/quantity"
 
 const (
-	infScaleMicro inf.Scale = 6 // 10^-6
+	infScaleMicro = quantity.ScaleMicro
 )
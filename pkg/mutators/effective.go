@@ -0,0 +1,107 @@
+package mutators
+
+import (
+	"github.com/kanopy-platform/hedgetrimmer/pkg/quantity"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// IsSidecar reports whether the init container is a native sidecar, it keeps running for the pod lifetime like an app
+// container.
+func IsSidecar(c corev1.Container) bool {
+	return c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// podMemory is the pod-level memory of a pod spec, which governs containers without own values.
+type podMemory struct {
+	request, limit       resource.Quantity
+	hasRequest, hasLimit bool
+}
+
+func podMemoryOf(spec corev1.PodSpec) podMemory {
+	var pod podMemory
+	if spec.Resources == nil {
+		return pod
+	}
+
+	pod.request, pod.hasRequest = spec.Resources.Requests[corev1.ResourceMemory]
+	pod.limit, pod.hasLimit = spec.Resources.Limits[corev1.ResourceMemory]
+	return pod
+}
+
+// EffectiveMemory returns the memory request and limit the scheduler and kubelet account for the pod: pod-level
+// resources when set, otherwise the larger of the largest init container and the sum of the app containers and
+// sidecars. hasLimit is false when the pod is unbounded, i.e. a container has no memory limit and there is no
+// pod-level limit.
+func EffectiveMemory(spec corev1.PodSpec) (request, limit resource.Quantity, hasLimit bool) {
+	request, limit, hasLimit = containersMemory(spec)
+
+	pod := podMemoryOf(spec)
+	if pod.hasRequest {
+		request = pod.request
+	}
+	if pod.hasLimit {
+		limit, hasLimit = pod.limit, true
+	}
+
+	return request, limit, hasLimit
+}
+
+// containersMemory aggregates the memory of the containers: init containers run one after another next to the
+// sidecars started before them, so the pod needs the larger of the largest init container and the sum of the app
+// containers and sidecars.
+func containersMemory(spec corev1.PodSpec) (request, limit resource.Quantity, hasLimit bool) {
+	hasLimit = true
+
+	var sidecarRequest, sidecarLimit, initRequest, initLimit resource.Quantity
+	for _, c := range spec.InitContainers {
+		l, ok := c.Resources.Limits[corev1.ResourceMemory]
+		hasLimit = hasLimit && ok
+
+		if IsSidecar(c) {
+			sidecarRequest = quantity.Add(sidecarRequest, *c.Resources.Requests.Memory())
+			sidecarLimit = quantity.Add(sidecarLimit, l)
+			continue
+		}
+
+		initRequest = quantity.Max(initRequest, quantity.Add(sidecarRequest, *c.Resources.Requests.Memory()))
+		initLimit = quantity.Max(initLimit, quantity.Add(sidecarLimit, l))
+	}
+
+	request, limit = sidecarRequest, sidecarLimit
+	for _, c := range spec.Containers {
+		request = quantity.Add(request, *c.Resources.Requests.Memory())
+
+		l, ok := c.Resources.Limits[corev1.ResourceMemory]
+		hasLimit = hasLimit && ok
+		limit = quantity.Add(limit, l)
+	}
+
+	return quantity.Max(request, initRequest), quantity.Max(limit, initLimit), hasLimit
+}
+
+// largestMemory returns the largest memory request and limit of the containers, zero if none is set.
+func largestMemory(containers []corev1.Container) (request, limit resource.Quantity) {
+	for _, c := range containers {
+		request = quantity.Max(request, *c.Resources.Requests.Memory())
+		limit = quantity.Max(limit, *c.Resources.Limits.Memory())
+	}
+	return request, limit
+}
+
+// copyMissingMemory sets the memory request and limit of the container when they are missing.
+func copyMissingMemory(c *corev1.Container, request, limit resource.Quantity) {
+	if c.Resources.Requests.Memory().IsZero() && !request.IsZero() {
+		if c.Resources.Requests == nil {
+			c.Resources.Requests = corev1.ResourceList{}
+		}
+		c.Resources.Requests[corev1.ResourceMemory] = request.DeepCopy()
+	}
+
+	if c.Resources.Limits.Memory().IsZero() && !limit.IsZero() {
+		if c.Resources.Limits == nil {
+			c.Resources.Limits = corev1.ResourceList{}
+		}
+		c.Resources.Limits[corev1.ResourceMemory] = limit.DeepCopy()
+	}
+}
@@ -0,0 +1,135 @@
+package mutators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func memoryContainer(name, request, limit string) corev1.Container {
+	c := corev1.Container{Name: name}
+	if request != "" {
+		c.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(request)}
+	}
+	if limit != "" {
+		c.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)}
+	}
+	return c
+}
+
+func sidecarContainer(name, request, limit string) corev1.Container {
+	c := memoryContainer(name, request, limit)
+	always := corev1.ContainerRestartPolicyAlways
+	c.RestartPolicy = &always
+	return c
+}
+
+func TestEffectiveMemory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg          string
+		spec         corev1.PodSpec
+		wantRequest  string
+		wantLimit    string
+		wantHasLimit bool
+	}{
+		{
+			msg: "App containers are summed",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				memoryContainer("a", "128Mi", "256Mi"),
+				memoryContainer("b", "64Mi", "128Mi"),
+			}},
+			wantRequest:  "192Mi",
+			wantLimit:    "384Mi",
+			wantHasLimit: true,
+		},
+		{
+			msg: "The largest init container wins over the sum",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					memoryContainer("init-a", "1Gi", "1Gi"),
+					memoryContainer("init-b", "64Mi", "64Mi"),
+				},
+				Containers: []corev1.Container{
+					memoryContainer("a", "128Mi", "256Mi"),
+					memoryContainer("b", "64Mi", "128Mi"),
+				},
+			},
+			wantRequest:  "1Gi",
+			wantLimit:    "1Gi",
+			wantHasLimit: true,
+		},
+		{
+			msg: "Init containers smaller than the sum do not count",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{memoryContainer("init", "128Mi", "128Mi")},
+				Containers: []corev1.Container{
+					memoryContainer("a", "128Mi", "256Mi"),
+					memoryContainer("b", "64Mi", "128Mi"),
+				},
+			},
+			wantRequest:  "192Mi",
+			wantLimit:    "384Mi",
+			wantHasLimit: true,
+		},
+		{
+			msg: "Sidecars are added to the app containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					sidecarContainer("proxy", "64Mi", "128Mi"),
+					memoryContainer("init", "128Mi", "128Mi"),
+				},
+				Containers: []corev1.Container{memoryContainer("a", "128Mi", "256Mi")},
+			},
+			wantRequest:  "192Mi",
+			wantLimit:    "384Mi",
+			wantHasLimit: true,
+		},
+		{
+			msg: "Init containers run next to the sidecars started before them",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					memoryContainer("first", "1Gi", "1Gi"),
+					sidecarContainer("proxy", "64Mi", "128Mi"),
+					memoryContainer("migrate", "1Gi", "1Gi"),
+				},
+				Containers: []corev1.Container{memoryContainer("a", "128Mi", "256Mi")},
+			},
+			wantRequest:  "1088Mi",
+			wantLimit:    "1152Mi",
+			wantHasLimit: true,
+		},
+		{
+			msg: "Pod-level resources govern the pod",
+			spec: corev1.PodSpec{
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				Containers: []corev1.Container{memoryContainer("a", "128Mi", ""), memoryContainer("b", "", "")},
+			},
+			wantRequest:  "512Mi",
+			wantLimit:    "1Gi",
+			wantHasLimit: true,
+		},
+		{
+			msg: "A container without limit leaves the pod unbounded",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				memoryContainer("a", "128Mi", "256Mi"),
+				memoryContainer("b", "64Mi", ""),
+			}},
+			wantRequest: "192Mi",
+			wantLimit:   "256Mi",
+		},
+	}
+
+	for _, test := range tests {
+		request, limit, hasLimit := EffectiveMemory(test.spec)
+		assert.True(t, request.Equal(resource.MustParse(test.wantRequest)), "%s: request %s", test.msg, request.String())
+		assert.True(t, limit.Equal(resource.MustParse(test.wantLimit)), "%s: limit %s", test.msg, limit.String())
+		assert.Equal(t, test.wantHasLimit, hasLimit, test.msg)
+	}
+}
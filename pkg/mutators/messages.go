@@ -0,0 +1,165 @@
+package mutators
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/template"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Names of the messages which can be replaced by templates.
+const (
+	MessageMissing           = "missing"
+	MessageLimitBelowRequest = "limitBelowRequest"
+	MessageBelowMin          = "belowMin"
+	MessageAboveMax          = "aboveMax"
+	MessageRatio             = "ratio"
+	// MessagePodAboveMax is the denial for pods whose effective memory limit exceeds the Pod Max.
+	MessagePodAboveMax = "podAboveMax"
+	// MessageAbovePodLimit and MessagePodRequestBelowContainers are the denials for containers inconsistent with the
+	// pod-level resources.
+	MessageAbovePodLimit             = "abovePodLimit"
+	MessagePodRequestBelowContainers = "podRequestBelowContainers"
+	// MessageSuggestion is the warning returned per container in warn mode.
+	MessageSuggestion = "suggestion"
+	// MessageSuggestDenied is the denial in suggest mode, with the YAML snippet to add as Suggestion.
+	MessageSuggestDenied = "suggestDenied"
+	// MessageMutateFailed and MessageResizeDenied are the denials of the admission handlers, wrapping the violations
+	// as Error.
+	MessageMutateFailed = "mutateFailed"
+	MessageResizeDenied = "resizeDenied"
+	// MessageInvalidConfig is the denial when the LimitRange config of the namespace is missing.
+	MessageInvalidConfig = "invalidConfig"
+	// MessageUnconfigured is the denial in namespaces without a Container LimitRange.
+	MessageUnconfigured = "unconfigured"
+)
+
+var defaultMessages = map[string]string{
+	MessageMissing:                   `container {{printf "%q" .Container}}: memory request ({{.Request}}) and limit ({{.Limit}}) must be set`,
+	MessageLimitBelowRequest:         `container {{printf "%q" .Container}}: memory limit ({{.Limit}}) must be greater than request ({{.Request}})`,
+	MessageBelowMin:                  `container {{printf "%q" .Container}}: memory request ({{.Request}}) is less than Min ({{.Min}})`,
+	MessageAboveMax:                  `container {{printf "%q" .Container}}: memory limit ({{.Limit}}) exceeds Max ({{.Max}})`,
+	MessageRatio:                     `container {{printf "%q" .Container}}: memory limit ({{.Limit}}) to request ({{.Request}}) ratio ({{.Ratio}}) exceeds MaxLimitRequestRatio ({{.MaxLimitRequestRatio}})`,
+	MessagePodAboveMax:               `pod: effective memory limit ({{.Limit}}{{if .Overhead}} including the RuntimeClass overhead of {{.Overhead}}{{end}}) exceeds the Pod Max ({{.Max}})`,
+	MessageAbovePodLimit:             `container {{printf "%q" .Container}}: memory limit ({{.Limit}}) exceeds the pod memory limit ({{.PodLimit}})`,
+	MessagePodRequestBelowContainers: `pod: memory request ({{.PodRequest}}) is less than the sum of the container requests ({{.Request}})`,
+	MessageSuggestDenied:             "memory resources must be set explicitly, add the following to the pod spec:\n{{.Suggestion}}",
+	MessageMutateFailed:              `failed to mutate {{.Kind}} {{.Namespace}}/{{.Name}}: {{.Error}}`,
+	MessageResizeDenied:              `invalid resize of {{.Kind}} {{.Namespace}}/{{.Name}}: {{.Error}}`,
+	MessageInvalidConfig:             `invalid LimitRange config for namespace: {{.Namespace}}`,
+	MessageUnconfigured:              `No container limit range in namespace: {{.Namespace}}`,
+	MessageSuggestion:                `{{if .InitContainer}}init {{end}}container {{printf "%q" .Container}}: set resources.requests.memory: {{.Request}} and resources.limits.memory: {{.Limit}}`,
+}
+
+// MessageData is passed to the message templates, quantities are empty when not set.
+type MessageData struct {
+	Container string
+	// InitContainer is only set for suggestions, violations carry the field path instead.
+	InitContainer        bool
+	Namespace            string
+	LimitRange           string
+	Request              string
+	Limit                string
+	Ratio                string
+	Min                  string
+	Max                  string
+	MaxLimitRequestRatio string
+	// PodRequest and PodLimit are the pod-level resources, only set for the pod-level messages.
+	PodRequest string
+	PodLimit   string
+	// Overhead is the memory overhead of the RuntimeClass included in the effective pod memory.
+	Overhead string
+	// Kind, Name and Error describe the denied object, only set for the denials of the admission handlers.
+	Kind  string
+	Name  string
+	Error string
+	// Suggestion is the YAML snippet of the suggest mode denial.
+	Suggestion string
+}
+
+// Messages renders denial and warning messages from Go templates.
+type Messages struct {
+	templates map[string]*template.Template
+}
+
+// NewMessages parses the templates by message name, messages without a template keep their default.
+func NewMessages(templates map[string]string) (*Messages, error) {
+	m := &Messages{templates: map[string]*template.Template{}}
+
+	for name, text := range defaultMessages {
+		m.templates[name] = template.Must(template.New(name).Parse(text))
+	}
+
+	for name, text := range templates {
+		if _, ok := defaultMessages[name]; !ok {
+			return nil, fmt.Errorf("unknown message %q", name)
+		}
+
+		t, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for message %q: %w", name, err)
+		}
+
+		// unknown fields only fail on execution
+		if err := t.Execute(io.Discard, MessageData{}); err != nil {
+			return nil, fmt.Errorf("invalid template for message %q: %w", name, err)
+		}
+		m.templates[name] = t
+	}
+
+	return m, nil
+}
+
+// DefaultMessages returns the default messages.
+func DefaultMessages() *Messages {
+	m, _ := NewMessages(nil)
+	return m
+}
+
+// MessageNames returns the sorted names of the messages which can be replaced by templates.
+func MessageNames() []string {
+	names := make([]string, 0, len(defaultMessages))
+	for name := range defaultMessages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders the message with data.
+func (m *Messages) Render(name string, data MessageData) string {
+	var b bytes.Buffer
+	if err := m.templates[name].Execute(&b, data); err != nil {
+		return fmt.Sprintf("failed to render message %q: %s", name, err)
+	}
+	return b.String()
+}
+
+// messageData returns the data describing the container and the Config.
+func messageData(ctx context.Context, container corev1.Container, cfg *limitrange.Config) MessageData {
+	data := MessageData{
+		Container:  container.Name,
+		Namespace:  admission.NamespaceFromContext(ctx),
+		LimitRange: cfg.LimitRangeName,
+		Request:    container.Resources.Requests.Memory().String(),
+		Limit:      container.Resources.Limits.Memory().String(),
+	}
+
+	if cfg.HasMin {
+		data.Min = cfg.Min.String()
+	}
+	if cfg.HasMax {
+		data.Max = cfg.Max.String()
+	}
+	if cfg.HasMaxLimitRequestRatio {
+		data.MaxLimitRequestRatio = cfg.MaxLimitRequestRatio.String()
+	}
+
+	return data
+}
@@ -0,0 +1,107 @@
+package mutators
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestNewMessages(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg       string
+		templates map[string]string
+		wantError bool
+	}{
+		{
+			msg:       "Valid template",
+			templates: map[string]string{MessageAboveMax: "{{.Container}} in {{.Namespace}}"},
+		},
+		{
+			msg:       "Unknown message",
+			templates: map[string]string{"unknown": "{{.Container}}"},
+			wantError: true,
+		},
+		{
+			msg:       "Invalid syntax",
+			templates: map[string]string{MessageAboveMax: "{{.Container"},
+			wantError: true,
+		},
+		{
+			msg:       "Unknown field",
+			templates: map[string]string{MessageAboveMax: "{{.Pod}}"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewMessages(test.templates)
+		assert.Equal(t, test.wantError, err != nil, test.msg)
+	}
+}
+
+func TestMutateMessageTemplates(t *testing.T) {
+	t.Parallel()
+
+	messages, err := NewMessages(map[string]string{
+		MessageAboveMax:   "{{.Container}} in {{.Namespace}} exceeds {{.Max}} from {{.LimitRange}}",
+		MessageSuggestion: "{{.Container}}: {{.Request}}/{{.Limit}}",
+	})
+	assert.NoError(t, err)
+
+	ctx := admission.WithNamespace(admission.WithWarnings(context.Background()), "team-a")
+	config := &limitrange.Config{HasMax: true, Max: resource.MustParse("1Gi"), EnforceMinMax: true, LimitRangeName: "limits"}
+
+	pts := NewPodTemplateSpec(WithMessages(messages))
+	_, err = pts.Mutate(ctx, corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		},
+	}}}}, config)
+	assert.EqualError(t, err, "app in team-a exceeds 1Gi from limits")
+
+	pts = NewPodTemplateSpec(WithMessages(messages), WithEnforcementMode(limitrange.EnforcementModeWarn))
+	_, err = pts.Mutate(ctx, corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+	}}}}, config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app: 512Mi/563Mi"}, admission.WarningsFromContext(ctx))
+}
+
+func TestSuggestDeniedMessageTemplate(t *testing.T) {
+	t.Parallel()
+
+	messages, err := NewMessages(map[string]string{MessageSuggestDenied: "set memory in {{.Namespace}}:\n{{.Suggestion}}"})
+	assert.NoError(t, err)
+
+	ctx := admission.WithNamespace(context.Background(), "team-a")
+	pts := NewPodTemplateSpec(WithMessages(messages), WithEnforcementMode(limitrange.EnforcementModeSuggest))
+	_, err = pts.Mutate(ctx, corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+		memoryContainer("app", "512Mi", ""),
+	}}}, &limitrange.Config{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "set memory in team-a:\ncontainers:\n- name: app")
+	}
+}
+
+func TestMessageNames(t *testing.T) {
+	t.Parallel()
+
+	names := MessageNames()
+	assert.Len(t, names, len(defaultMessages))
+	assert.Contains(t, names, MessagePodAboveMax)
+	assert.Contains(t, names, MessageAbovePodLimit)
+	assert.Contains(t, names, MessagePodRequestBelowContainers)
+	assert.IsIncreasing(t, names)
+}
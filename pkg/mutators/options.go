@@ -3,11 +3,36 @@ package mutators
 import (
 	"fmt"
 
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
 	"k8s.io/apimachinery/pkg/api/resource"
+	nodev1Listers "k8s.io/client-go/listers/node/v1"
 )
 
 type OptionsFunc func(*PodTemplateSpec)
 
+// InitContainerPolicy controls how memory resources of init containers are set, native sidecars always get the app
+// container policy.
+type InitContainerPolicy string
+
+const (
+	// InitContainerPolicyDefault sets init containers like app containers, with their own limit/request ratio.
+	InitContainerPolicyDefault InitContainerPolicy = "default"
+	// InitContainerPolicyCopyLargest sets missing values to the largest values of the app containers, init containers
+	// run before them and need no more memory than the pod already reserves.
+	InitContainerPolicyCopyLargest InitContainerPolicy = "copy-largest"
+	// InitContainerPolicySkip neither sets nor validates init containers.
+	InitContainerPolicySkip InitContainerPolicy = "skip"
+)
+
+func ParseInitContainerPolicy(policy string) (InitContainerPolicy, error) {
+	switch p := InitContainerPolicy(policy); p {
+	case InitContainerPolicyDefault, InitContainerPolicyCopyLargest, InitContainerPolicySkip:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown init container policy: %q", policy)
+	}
+}
+
 func WithDefaultMemoryLimitRequestRatio(ratio float64) OptionsFunc {
 	return func(pts *PodTemplateSpec) {
 		pts.defaultMemoryLimitRequestRatio = resource.MustParse(fmt.Sprintf("%v", ratio))
@@ -19,3 +44,46 @@ func WithDryRun(dryRun bool) OptionsFunc {
 		pts.dryRun = dryRun
 	}
 }
+
+func WithEnforcementMode(mode limitrange.EnforcementMode) OptionsFunc {
+	return func(pts *PodTemplateSpec) {
+		pts.enforcementMode = mode
+	}
+}
+
+// WithMessages replaces the default denial and warning messages.
+func WithMessages(messages *Messages) OptionsFunc {
+	return func(pts *PodTemplateSpec) {
+		pts.messages = messages
+	}
+}
+
+func WithInitContainerPolicy(policy InitContainerPolicy) OptionsFunc {
+	return func(pts *PodTemplateSpec) {
+		pts.initContainerPolicy = policy
+	}
+}
+
+// WithInitContainerMemoryLimitRequestRatio sets the ratio of init containers, which defaults to the app ratio.
+func WithInitContainerMemoryLimitRequestRatio(ratio float64) OptionsFunc {
+	return func(pts *PodTemplateSpec) {
+		r := resource.MustParse(fmt.Sprintf("%v", ratio))
+		pts.initMemoryLimitRequestRatio = &r
+	}
+}
+
+// WithRuntimeClassLister adds the overhead of the RuntimeClass to the pod memory of templates, pods get it set on
+// admission.
+func WithRuntimeClassLister(lister nodev1Listers.RuntimeClassLister) OptionsFunc {
+	return func(pts *PodTemplateSpec) {
+		pts.runtimeClasses = lister
+	}
+}
+
+// WithLimitRangeEnforcementMode controls whether the enforcement mode annotation of a LimitRange overrides the
+// configured mode, it does by default.
+func WithLimitRangeEnforcementMode(enabled bool) OptionsFunc {
+	return func(pts *PodTemplateSpec) {
+		pts.ignoreLimitRangeMode = !enabled
+	}
+}
@@ -5,22 +5,35 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/quantity"
 	"gopkg.in/inf.v0"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	nodev1Listers "k8s.io/client-go/listers/node/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 type PodTemplateSpec struct {
 	dryRun                         bool
 	defaultMemoryLimitRequestRatio resource.Quantity
+	enforcementMode                limitrange.EnforcementMode
+	messages                       *Messages
+	initContainerPolicy            InitContainerPolicy
+	initMemoryLimitRequestRatio    *resource.Quantity
+	runtimeClasses                 nodev1Listers.RuntimeClassLister
+	ignoreLimitRangeMode           bool
 }
 
 func NewPodTemplateSpec(opts ...OptionsFunc) *PodTemplateSpec {
 	pts := &PodTemplateSpec{
 		defaultMemoryLimitRequestRatio: resource.MustParse("1.1"),
+		enforcementMode:                limitrange.EnforcementModeMutate,
+		messages:                       DefaultMessages(),
+		initContainerPolicy:            InitContainerPolicyDefault,
 	}
 
 	for _, opt := range opts {
@@ -30,44 +43,248 @@ func NewPodTemplateSpec(opts ...OptionsFunc) *PodTemplateSpec {
 	return pts
 }
 
+// Messages returns the templates of the denial and warning messages.
+func (p *PodTemplateSpec) Messages() *Messages {
+	return p.messages
+}
+
 func (p *PodTemplateSpec) Mutate(ctx context.Context, inputPts corev1.PodTemplateSpec, limitRangeMemory *limitrange.Config) (corev1.PodTemplateSpec, error) {
 
 	pts := *inputPts.DeepCopy()
 	if limitRangeMemory == nil {
-		return pts, p.errorIfNotDryRun(ctx, "invalid limit range config")
+		return pts, p.errorIfNotDryRun(ctx, errors.New("invalid limit range config"))
+	}
+
+	// every container is checked so all violations are reported at once, app containers are set first so init
+	// containers can copy their values
+	pod := podMemoryOf(pts.Spec)
+	appViolations := p.setAndValidateResourceRequirements(ctx, pts.Spec.Containers, field.NewPath("containers"), p.defaultMemoryLimitRequestRatio, nil, pod, limitRangeMemory)
+	violations := append(p.setAndValidateInitContainers(ctx, &pts.Spec, pod, limitRangeMemory), appViolations...)
+	violations = append(violations, p.validatePodRequirements(ctx, pts.Spec, limitRangeMemory)...)
+
+	if len(violations) > 0 {
+		if err := p.errorIfNotDryRun(ctx, admission.Violations(violations)); err != nil {
+			return pts, err
+		}
+	}
+
+	return p.enforce(ctx, inputPts, pts, limitRangeMemory)
+}
+
+// Validate validates the memory resources of spec as they are, e.g. for a resize of a running pod. Violations are
+// returned as warnings in the warn enforcement mode.
+func (p *PodTemplateSpec) Validate(ctx context.Context, spec corev1.PodSpec, limitRangeMemory *limitrange.Config) error {
+	if limitRangeMemory == nil {
+		return p.errorIfNotDryRun(ctx, errors.New("invalid limit range config"))
+	}
+
+	var violations field.ErrorList
+	pod := podMemoryOf(spec)
+
+	initPath := field.NewPath("initContainers")
+	for idx, container := range spec.InitContainers {
+		if p.initContainerPolicy == InitContainerPolicySkip && !IsSidecar(container) {
+			continue
+		}
+		violations = append(violations, p.validateMemoryRequirements(ctx, container, initPath.Index(idx), pod, limitRangeMemory)...)
 	}
 
-	if err := p.setAndValidateResourceRequirements(ctx, pts.Spec.InitContainers, limitRangeMemory); err != nil {
-		return pts, err
+	path := field.NewPath("containers")
+	for idx, container := range spec.Containers {
+		violations = append(violations, p.validateMemoryRequirements(ctx, container, path.Index(idx), pod, limitRangeMemory)...)
+	}
+
+	violations = append(violations, p.validatePodRequirements(ctx, spec, limitRangeMemory)...)
+
+	if len(violations) == 0 {
+		return nil
 	}
 
-	if err := p.setAndValidateResourceRequirements(ctx, pts.Spec.Containers, limitRangeMemory); err != nil {
-		return pts, err
+	if !p.dryRun && p.mode(limitRangeMemory) == limitrange.EnforcementModeWarn {
+		for _, v := range violations {
+			admission.AddWarning(ctx, v.Detail)
+		}
+		return nil
 	}
 
-	return pts, nil
+	return p.errorIfNotDryRun(ctx, admission.Violations(violations))
 }
 
-func (p *PodTemplateSpec) setAndValidateResourceRequirements(ctx context.Context, containers []corev1.Container, limitRangeMemory *limitrange.Config) error {
-	for idx := range containers {
-		container := &containers[idx]
-		if p.dryRun {
-			// On dry-run use a copy to go through the motions, do not modify original
-			container = container.DeepCopy()
+// mode returns the enforcement mode of the LimitRange, falling back to the configured one.
+func (p *PodTemplateSpec) mode(limitRangeMemory *limitrange.Config) limitrange.EnforcementMode {
+	if limitRangeMemory.EnforcementMode != "" && !p.ignoreLimitRangeMode {
+		return limitRangeMemory.EnforcementMode
+	}
+	return p.enforcementMode
+}
+
+// enforce applies the enforcement mode to the mutated PodTemplateSpec. Unless mutating, the computed values are
+// handed back to the user in a denial or warnings and the input is returned unmodified.
+func (p *PodTemplateSpec) enforce(ctx context.Context, inputPts, pts corev1.PodTemplateSpec, limitRangeMemory *limitrange.Config) (corev1.PodTemplateSpec, error) {
+	mode := p.mode(limitRangeMemory)
+
+	if p.dryRun || mode == limitrange.EnforcementModeMutate {
+		return pts, nil
+	}
+
+	suggestion := suggestionsFor(inputPts.Spec, pts.Spec)
+	if suggestion.empty() {
+		return inputPts, nil
+	}
+
+	if mode == limitrange.EnforcementModeWarn {
+		for _, warning := range suggestion.warnings(ctx, p.messages, limitRangeMemory) {
+			admission.AddWarning(ctx, warning)
 		}
+		return inputPts, nil
+	}
+
+	snippet, err := suggestion.YAML()
+	if err != nil {
+		return inputPts, err
+	}
+
+	data := messageData(ctx, corev1.Container{}, limitRangeMemory)
+	data.Request, data.Limit = "", ""
+	data.Suggestion = snippet
+	return inputPts, errors.New(p.messages.Render(MessageSuggestDenied, data))
+}
+
+// setAndValidateResourceRequirements sets missing values, computing missing limits with ratio unless the LimitRange has
+// a MaxLimitRequestRatio. fill, if set, is applied to each container first. Values governed by pod-level resources are
+// not set.
+func (p *PodTemplateSpec) setAndValidateResourceRequirements(ctx context.Context, containers []corev1.Container, path *field.Path, ratio resource.Quantity, fill func(*corev1.Container), pod podMemory, limitRangeMemory *limitrange.Config) field.ErrorList {
+	var violations field.ErrorList
 
+	for idx := range containers {
+		violations = append(violations, p.setAndValidateContainer(ctx, &containers[idx], path.Index(idx), ratio, fill, pod, limitRangeMemory)...)
+	}
+
+	return violations
+}
+
+func (p *PodTemplateSpec) setAndValidateContainer(ctx context.Context, container *corev1.Container, path *field.Path, ratio resource.Quantity, fill func(*corev1.Container), pod podMemory, limitRangeMemory *limitrange.Config) field.ErrorList {
+	if p.dryRun {
+		// On dry-run use a copy to go through the motions, do not modify original
+		container = container.DeepCopy()
+	}
+
+	if fill != nil {
+		fill(container)
+	}
+
+	if !pod.hasRequest {
 		p.setMemoryRequest(ctx, container, limitRangeMemory)
-		p.setMemoryLimit(ctx, container, limitRangeMemory)
+	}
+	if !pod.hasLimit {
+		p.setMemoryLimitWithRatio(ctx, container, ratio, limitRangeMemory)
+	}
+
+	return p.validateMemoryRequirements(ctx, *container, path, pod, limitRangeMemory)
+}
+
+// setAndValidateInitContainers applies the init container policy. Native sidecars run for the pod lifetime and are
+// set like app containers instead.
+func (p *PodTemplateSpec) setAndValidateInitContainers(ctx context.Context, spec *corev1.PodSpec, pod podMemory, limitRangeMemory *limitrange.Config) field.ErrorList {
+	ratio := p.defaultMemoryLimitRequestRatio
+	if p.initMemoryLimitRequestRatio != nil {
+		ratio = *p.initMemoryLimitRequestRatio
+	}
+
+	var fill func(*corev1.Container)
+	if p.initContainerPolicy == InitContainerPolicyCopyLargest {
+		request, limit := largestMemory(spec.Containers)
+		fill = func(c *corev1.Container) {
+			copyMissingMemory(c, request, limit)
+		}
+	}
+
+	var violations field.ErrorList
+	path := field.NewPath("initContainers")
+
+	for idx := range spec.InitContainers {
+		container := &spec.InitContainers[idx]
+
+		switch {
+		case IsSidecar(*container):
+			violations = append(violations, p.setAndValidateContainer(ctx, container, path.Index(idx), p.defaultMemoryLimitRequestRatio, nil, pod, limitRangeMemory)...)
+		case p.initContainerPolicy != InitContainerPolicySkip:
+			violations = append(violations, p.setAndValidateContainer(ctx, container, path.Index(idx), ratio, fill, pod, limitRangeMemory)...)
+		}
+	}
+
+	return violations
+}
 
-		if err := p.validateMemoryRequirements(ctx, *container, limitRangeMemory); err != nil {
-			return p.errorIfNotDryRun(ctx, err.Error())
+// validatePodRequirements validates the pod-level resources against the containers and the effective memory of the
+// pod, including the RuntimeClass overhead, against the Pod Max.
+func (p *PodTemplateSpec) validatePodRequirements(ctx context.Context, spec corev1.PodSpec, limitRangeMemory *limitrange.Config) field.ErrorList {
+	var violations field.ErrorList
+
+	if pod := podMemoryOf(spec); pod.hasRequest {
+		containersRequest, _, _ := containersMemory(spec)
+		if containersRequest.Cmp(pod.request) == 1 {
+			data := messageData(ctx, corev1.Container{}, limitRangeMemory)
+			data.Request = containersRequest.String()
+			data.PodRequest = pod.request.String()
+			violations = append(violations, field.Invalid(field.NewPath("resources", "requests", "memory"), pod.request.String(), p.messages.Render(MessagePodRequestBelowContainers, data)))
 		}
 	}
 
-	return nil
+	if !limitRangeMemory.HasPodMax {
+		return violations
+	}
+
+	_, limit, hasLimit := EffectiveMemory(spec)
+	overhead := p.memoryOverhead(ctx, spec)
+	limit = quantity.Add(limit, overhead)
+	if !hasLimit || limit.Cmp(limitRangeMemory.PodMax) <= 0 {
+		return violations
+	}
+
+	data := messageData(ctx, corev1.Container{}, limitRangeMemory)
+	data.Request = ""
+	data.Limit = limit.String()
+	data.Max = limitRangeMemory.PodMax.String()
+	if !overhead.IsZero() {
+		data.Overhead = overhead.String()
+	}
+
+	path := field.NewPath("containers")
+	if spec.Resources != nil && !spec.Resources.Limits.Memory().IsZero() {
+		path = field.NewPath("resources", "limits", "memory")
+	}
+
+	return append(violations, field.Invalid(path, limit.String(), p.messages.Render(MessagePodAboveMax, data)))
 }
 
-func (p *PodTemplateSpec) errorIfNotDryRun(ctx context.Context, err string) error {
+// memoryOverhead returns the memory overhead of the pod: the overhead set by the RuntimeClass admission on pods, or
+// the overhead of the RuntimeClass for pod templates which are not admitted yet.
+func (p *PodTemplateSpec) memoryOverhead(ctx context.Context, spec corev1.PodSpec) resource.Quantity {
+	if spec.Overhead != nil {
+		return *spec.Overhead.Memory()
+	}
+
+	if spec.RuntimeClassName == nil || p.runtimeClasses == nil {
+		return resource.Quantity{}
+	}
+
+	rc, err := p.runtimeClasses.Get(*spec.RuntimeClassName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "failed to get runtimeclass", "runtimeclass", *spec.RuntimeClassName)
+		}
+		return resource.Quantity{}
+	}
+
+	if rc.Overhead == nil {
+		return resource.Quantity{}
+	}
+
+	return *rc.Overhead.PodFixed.Memory()
+}
+
+func (p *PodTemplateSpec) errorIfNotDryRun(ctx context.Context, err error) error {
 	log := log.FromContext(ctx)
 
 	if p.dryRun {
@@ -75,30 +292,56 @@ func (p *PodTemplateSpec) errorIfNotDryRun(ctx context.Context, err string) erro
 		return nil
 	}
 
-	return errors.New(err)
+	return err
 }
 
-func (p *PodTemplateSpec) validateMemoryRequirements(ctx context.Context, container corev1.Container, limitRangeMemory *limitrange.Config) error {
+// validateMemoryRequirements returns the violations of the container at path. Values governed by pod-level resources
+// may be missing.
+func (p *PodTemplateSpec) validateMemoryRequirements(ctx context.Context, container corev1.Container, path *field.Path, pod podMemory, limitRangeMemory *limitrange.Config) field.ErrorList {
 	memoryRequest := container.Resources.Requests.Memory()
 	memoryLimit := container.Resources.Limits.Memory()
+	hasRequest, hasLimit := !memoryRequest.IsZero(), !memoryLimit.IsZero()
+	requestPath := path.Child("resources", "requests", "memory")
+	limitPath := path.Child("resources", "limits", "memory")
+
+	data := messageData(ctx, container, limitRangeMemory)
 
-	if memoryRequest.IsZero() || memoryLimit.IsZero() {
-		return fmt.Errorf("container %q: memory request (%s) and limit (%s) must be set", container.Name, memoryRequest.String(), memoryLimit.String())
+	if (!hasRequest && !pod.hasRequest) || (!hasLimit && !pod.hasLimit) {
+		missing := limitPath
+		if !hasRequest && !pod.hasRequest {
+			missing = requestPath
+		}
+		return field.ErrorList{field.Required(missing, p.messages.Render(MessageMissing, data))}
 	}
 
-	if memoryLimit.Cmp(*memoryRequest) == -1 {
-		return fmt.Errorf("container %q: memory limit (%s) must be greater than request (%s)", container.Name, memoryLimit.String(), memoryRequest.String())
+	if hasRequest && hasLimit && memoryLimit.Cmp(*memoryRequest) == -1 {
+		return field.ErrorList{field.Invalid(limitPath, memoryLimit.String(), p.messages.Render(MessageLimitBelowRequest, data))}
 	}
 
-	if limitRangeMemory.HasMaxLimitRequestRatio {
+	var violations field.ErrorList
+
+	if hasRequest && limitRangeMemory.EnforceMinMax && limitRangeMemory.HasMin && memoryRequest.Cmp(limitRangeMemory.Min) == -1 {
+		violations = append(violations, field.Invalid(requestPath, memoryRequest.String(), p.messages.Render(MessageBelowMin, data)))
+	}
+
+	if hasLimit && limitRangeMemory.EnforceMinMax && limitRangeMemory.HasMax && memoryLimit.Cmp(limitRangeMemory.Max) == 1 {
+		violations = append(violations, field.Invalid(limitPath, memoryLimit.String(), p.messages.Render(MessageAboveMax, data)))
+	}
+
+	if hasLimit && pod.hasLimit && memoryLimit.Cmp(pod.limit) == 1 {
+		data.PodLimit = pod.limit.String()
+		violations = append(violations, field.Invalid(limitPath, memoryLimit.String(), p.messages.Render(MessageAbovePodLimit, data)))
+	}
+
+	if hasRequest && hasLimit && limitRangeMemory.HasMaxLimitRequestRatio {
 		ratio := quantity.Div(*memoryLimit, *memoryRequest, infScaleMicro, inf.RoundUp)
 		if ratio.Cmp(limitRangeMemory.MaxLimitRequestRatio) == 1 {
-			return fmt.Errorf("container %q: memory limit (%s) to request (%s) ratio (%s) exceeds MaxLimitRequestRatio (%s)",
-				container.Name, memoryLimit.String(), memoryRequest.String(), ratio.String(), limitRangeMemory.MaxLimitRequestRatio.String())
+			data.Ratio = ratio.String()
+			violations = append(violations, field.Invalid(limitPath, memoryLimit.String(), p.messages.Render(MessageRatio, data)))
 		}
 	}
 
-	return nil
+	return violations
 }
 
 func (p *PodTemplateSpec) setMemoryRequest(ctx context.Context, container *corev1.Container, limitRangeMemory *limitrange.Config) {
@@ -131,6 +374,10 @@ func (p *PodTemplateSpec) setMemoryRequest(ctx context.Context, container *corev
 }
 
 func (p *PodTemplateSpec) setMemoryLimit(ctx context.Context, container *corev1.Container, limitRangeMemory *limitrange.Config) {
+	p.setMemoryLimitWithRatio(ctx, container, p.defaultMemoryLimitRequestRatio, limitRangeMemory)
+}
+
+func (p *PodTemplateSpec) setMemoryLimitWithRatio(ctx context.Context, container *corev1.Container, ratio resource.Quantity, limitRangeMemory *limitrange.Config) {
 	log := log.FromContext(ctx)
 	memoryRequest := container.Resources.Requests.Memory()
 	memoryLimit := container.Resources.Limits.Memory()
@@ -148,10 +395,15 @@ func (p *PodTemplateSpec) setMemoryLimit(ctx context.Context, container *corev1.
 	if limitRangeMemory.HasMaxLimitRequestRatio && !memoryRequest.IsZero() {
 		calculatedLimit = quantity.RoundBinarySI(quantity.Mul(*memoryRequest, limitRangeMemory.MaxLimitRequestRatio), inf.RoundDown)
 	} else {
-		ratioMemoryLimit := quantity.RoundBinarySI(quantity.Mul(*memoryRequest, p.defaultMemoryLimitRequestRatio), inf.RoundDown)
+		ratioMemoryLimit := quantity.RoundBinarySI(quantity.Mul(*memoryRequest, ratio), inf.RoundDown)
 		calculatedLimit = quantity.Max(limitRangeMemory.DefaultLimit, ratioMemoryLimit)
 	}
 
+	// a computed limit never exceeds Max, unless the request already does
+	if limitRangeMemory.EnforceMinMax && limitRangeMemory.HasMax && calculatedLimit.Cmp(limitRangeMemory.Max) == 1 && memoryRequest.Cmp(limitRangeMemory.Max) <= 0 {
+		calculatedLimit = limitRangeMemory.Max
+	}
+
 	if !calculatedLimit.IsZero() {
 		log.Info(fmt.Sprintf("container %q: setting memory limit to %s", container.Name, calculatedLimit.String()))
 		container.Resources.Limits[corev1.ResourceMemory] = calculatedLimit
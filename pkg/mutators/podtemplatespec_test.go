@@ -4,10 +4,16 @@ import (
 	"context"
 	"testing"
 
+	"github.com/kanopy-platform/hedgetrimmer/pkg/admission"
 	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	nodev1Listers "k8s.io/client-go/listers/node/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestMutate(t *testing.T) {
@@ -109,6 +115,189 @@ func TestMutate(t *testing.T) {
 	}
 }
 
+func TestMutateAggregatesViolations(t *testing.T) {
+	t.Parallel()
+
+	pts := NewPodTemplateSpec()
+	config := &limitrange.Config{
+		HasMin: true, Min: resource.MustParse("64Mi"),
+		HasMax: true, Max: resource.MustParse("1Gi"), EnforceMinMax: true,
+	}
+
+	input := corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{
+			Name: "init",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+		}},
+		Containers: []corev1.Container{
+			{
+				Name: "compliant",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+				},
+			},
+			{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				},
+			},
+		},
+	}}
+
+	_, err := pts.Mutate(context.Background(), input, config)
+
+	var violations admission.Violations
+	assert.ErrorAs(t, err, &violations)
+
+	var fields []string
+	for _, v := range violations {
+		fields = append(fields, v.Field)
+	}
+	assert.Equal(t, []string{
+		"initContainers[0].resources.requests.memory",
+		"containers[1].resources.requests.memory",
+		"containers[1].resources.limits.memory",
+	}, fields)
+	assert.Contains(t, err.Error(), `container "app": memory limit (2Gi) exceeds Max (1Gi)`)
+}
+
+func TestMutateInitContainerPolicy(t *testing.T) {
+	t.Parallel()
+
+	config := &limitrange.Config{HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi")}
+
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{memoryContainer("init", "100Mi", "")},
+		Containers: []corev1.Container{
+			memoryContainer("small", "128Mi", "128Mi"),
+			memoryContainer("large", "512Mi", "1Gi"),
+		},
+	}
+
+	tests := []struct {
+		msg      string
+		opts     []OptionsFunc
+		wantInit corev1.Container
+	}{
+		{
+			msg:      "Default policy uses the app ratio",
+			opts:     []OptionsFunc{WithDefaultMemoryLimitRequestRatio(1.5)},
+			wantInit: memoryContainer("init", "100Mi", "150Mi"),
+		},
+		{
+			msg:      "Default policy with an own ratio",
+			opts:     []OptionsFunc{WithDefaultMemoryLimitRequestRatio(1.5), WithInitContainerMemoryLimitRequestRatio(1)},
+			wantInit: memoryContainer("init", "100Mi", "100Mi"),
+		},
+		{
+			msg:      "Copy the largest app container values",
+			opts:     []OptionsFunc{WithInitContainerPolicy(InitContainerPolicyCopyLargest)},
+			wantInit: memoryContainer("init", "100Mi", "1Gi"),
+		},
+		{
+			msg:      "Skip init containers",
+			opts:     []OptionsFunc{WithInitContainerPolicy(InitContainerPolicySkip)},
+			wantInit: memoryContainer("init", "100Mi", ""),
+		},
+	}
+
+	for _, test := range tests {
+		pts := NewPodTemplateSpec(test.opts...)
+		result, err := pts.Mutate(context.Background(), corev1.PodTemplateSpec{Spec: *spec.DeepCopy()}, config)
+		assert.NoError(t, err, test.msg)
+		assert.Equal(t, test.wantInit.Resources.Requests.Memory().String(), result.Spec.InitContainers[0].Resources.Requests.Memory().String(), test.msg)
+		assert.Equal(t, test.wantInit.Resources.Limits.Memory().String(), result.Spec.InitContainers[0].Resources.Limits.Memory().String(), test.msg)
+	}
+
+	_, err := ParseInitContainerPolicy("unknown")
+	assert.Error(t, err)
+}
+
+func TestMutateSidecar(t *testing.T) {
+	t.Parallel()
+
+	config := &limitrange.Config{HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi")}
+
+	tests := []struct {
+		msg  string
+		opts []OptionsFunc
+	}{
+		{
+			msg:  "Sidecars use the app ratio",
+			opts: []OptionsFunc{WithDefaultMemoryLimitRequestRatio(1.5), WithInitContainerMemoryLimitRequestRatio(1)},
+		},
+		{
+			msg:  "Sidecars do not copy the largest app container",
+			opts: []OptionsFunc{WithDefaultMemoryLimitRequestRatio(1.5), WithInitContainerPolicy(InitContainerPolicyCopyLargest)},
+		},
+		{
+			msg:  "Sidecars are not skipped",
+			opts: []OptionsFunc{WithDefaultMemoryLimitRequestRatio(1.5), WithInitContainerPolicy(InitContainerPolicySkip)},
+		},
+	}
+
+	for _, test := range tests {
+		spec := corev1.PodSpec{
+			InitContainers: []corev1.Container{sidecarContainer("proxy", "100Mi", "")},
+			Containers:     []corev1.Container{memoryContainer("app", "512Mi", "1Gi")},
+		}
+
+		result, err := NewPodTemplateSpec(test.opts...).Mutate(context.Background(), corev1.PodTemplateSpec{Spec: spec}, config)
+		assert.NoError(t, err, test.msg)
+		assert.Equal(t, "150Mi", result.Spec.InitContainers[0].Resources.Limits.Memory().String(), test.msg)
+	}
+}
+
+func TestMutatePodMax(t *testing.T) {
+	t.Parallel()
+
+	config := &limitrange.Config{HasPodMax: true, PodMax: resource.MustParse("1Gi")}
+	pts := NewPodTemplateSpec()
+
+	tests := []struct {
+		msg       string
+		spec      corev1.PodSpec
+		wantError bool
+	}{
+		{
+			msg: "Effective limit within the Pod Max",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{memoryContainer("init", "1Gi", "1Gi")},
+				Containers:     []corev1.Container{memoryContainer("a", "512Mi", "512Mi"), memoryContainer("b", "512Mi", "512Mi")},
+			},
+		},
+		{
+			msg: "Sidecars count towards the Pod Max",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{sidecarContainer("proxy", "512Mi", "512Mi")},
+				Containers:     []corev1.Container{memoryContainer("a", "768Mi", "768Mi")},
+			},
+			wantError: true,
+		},
+		{
+			msg: "Sum of app containers exceeds the Pod Max",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{memoryContainer("a", "512Mi", "768Mi"), memoryContainer("b", "512Mi", "512Mi")},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := pts.Mutate(context.Background(), corev1.PodTemplateSpec{Spec: test.spec}, config)
+		assert.Equal(t, test.wantError, err != nil, test.msg)
+		if test.wantError {
+			assert.ErrorContains(t, err, "pod: effective memory limit (1280Mi) exceeds the Pod Max (1Gi)", test.msg)
+		}
+	}
+}
+
 func TestMutateDryRun(t *testing.T) {
 	t.Parallel()
 
@@ -244,6 +433,34 @@ func TestValidateMemoryRequirements(t *testing.T) {
 			mc:        memoryConfig,
 			wantError: true,
 		},
+		{
+			msg:       "Memory request is less than Min, error",
+			requests:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Mi")},
+			limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			mc:        &limitrange.Config{HasMin: true, Min: resource.MustParse("64Mi"), EnforceMinMax: true},
+			wantError: true,
+		},
+		{
+			msg:       "Memory limit exceeds Max, error",
+			requests:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+			mc:        &limitrange.Config{HasMax: true, Max: resource.MustParse("1Gi"), EnforceMinMax: true},
+			wantError: true,
+		},
+		{
+			msg:       "Memory request and limit within Min and Max, allow",
+			requests:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			mc:        &limitrange.Config{HasMin: true, Min: resource.MustParse("64Mi"), HasMax: true, Max: resource.MustParse("1Gi"), EnforceMinMax: true},
+			wantError: false,
+		},
+		{
+			msg:       "Min and Max of a LimitRange are left to the API server, allow",
+			requests:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Mi")},
+			limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+			mc:        &limitrange.Config{HasMin: true, Min: resource.MustParse("64Mi"), HasMax: true, Max: resource.MustParse("1Gi")},
+			wantError: false,
+		},
 	}
 
 	for _, test := range tests {
@@ -254,8 +471,8 @@ func TestValidateMemoryRequirements(t *testing.T) {
 			},
 		}
 
-		err := pts.validateMemoryRequirements(context.Background(), container, test.mc)
-		assert.Equal(t, test.wantError, err != nil, test.msg)
+		violations := pts.validateMemoryRequirements(context.Background(), container, field.NewPath("containers").Index(0), podMemory{}, test.mc)
+		assert.Equal(t, test.wantError, len(violations) > 0, test.msg)
 	}
 }
 
@@ -417,6 +634,44 @@ func TestSetMemoryLimit(t *testing.T) {
 			},
 			wantLimits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("65Gi")},
 		},
+		{
+			msg:      "Calculated limit exceeds Max, use Max",
+			requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			limits:   corev1.ResourceList{},
+			mc: &limitrange.Config{
+				HasMaxLimitRequestRatio: true,
+				HasMax:                  true,
+				MaxLimitRequestRatio:    resource.MustParse("2"),
+				Max:                     resource.MustParse("1536Mi"),
+				EnforceMinMax:           true,
+			},
+			wantLimits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1536Mi")},
+		},
+		{
+			msg:      "Calculated limit exceeds the Max of a LimitRange, keep the calculated limit",
+			requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			limits:   corev1.ResourceList{},
+			mc: &limitrange.Config{
+				HasMaxLimitRequestRatio: true,
+				HasMax:                  true,
+				MaxLimitRequestRatio:    resource.MustParse("2"),
+				Max:                     resource.MustParse("1536Mi"),
+			},
+			wantLimits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		},
+		{
+			msg:      "Request exceeds Max, do not cap the limit below the request",
+			requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+			limits:   corev1.ResourceList{},
+			mc: &limitrange.Config{
+				HasMaxLimitRequestRatio: true,
+				HasMax:                  true,
+				MaxLimitRequestRatio:    resource.MustParse("1"),
+				Max:                     resource.MustParse("1Gi"),
+				EnforceMinMax:           true,
+			},
+			wantLimits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+		},
 	}
 
 	for _, test := range tests {
@@ -433,3 +688,286 @@ func TestSetMemoryLimit(t *testing.T) {
 		assert.True(t, test.wantLimits.Memory().Equal(*container.Resources.Limits.Memory()), test.msg)
 	}
 }
+
+func TestMutateEnforcementMode(t *testing.T) {
+	t.Parallel()
+
+	limitRangeMemory := limitrange.Config{
+		HasDefaultRequest: true,
+		HasDefaultLimit:   true,
+		DefaultRequest:    resource.MustParse("50Mi"),
+		DefaultLimit:      resource.MustParse("64Mi"),
+	}
+
+	overridden := limitRangeMemory
+	overridden.EnforcementMode = limitrange.EnforcementModeMutate
+
+	tests := []struct {
+		msg          string
+		mode         limitrange.EnforcementMode
+		config       limitrange.Config
+		containers   []corev1.Container
+		wantMutated  bool
+		wantError    string
+		wantWarnings []string
+	}{
+		{
+			msg:          "Suggest mode denies with a resources snippet",
+			mode:         limitrange.EnforcementModeSuggest,
+			config:       limitRangeMemory,
+			containers:   []corev1.Container{{Name: "app"}},
+			wantError:    "containers:\n- name: app\n  resources:\n    limits:\n      memory: 64Mi\n    requests:\n      memory: 50Mi\n",
+			wantWarnings: nil,
+		},
+		{
+			msg:          "Warn mode allows unmodified with a warning per container",
+			mode:         limitrange.EnforcementModeWarn,
+			config:       limitRangeMemory,
+			containers:   []corev1.Container{{Name: "app"}},
+			wantWarnings: []string{`container "app": set resources.requests.memory: 50Mi and resources.limits.memory: 64Mi`},
+		},
+		{
+			msg:    "Suggest mode allows containers which already comply",
+			mode:   limitrange.EnforcementModeSuggest,
+			config: limitRangeMemory,
+			containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("50Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+				},
+			}},
+		},
+		{
+			msg:         "LimitRange annotation overrides the global mode",
+			mode:        limitrange.EnforcementModeSuggest,
+			config:      overridden,
+			containers:  []corev1.Container{{Name: "app"}},
+			wantMutated: true,
+		},
+	}
+
+	for _, test := range tests {
+		pts := NewPodTemplateSpec(WithEnforcementMode(test.mode))
+		input := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: test.containers}}
+		ctx := admission.WithWarnings(context.Background())
+
+		result, err := pts.Mutate(ctx, input, &test.config)
+		if test.wantError != "" {
+			assert.ErrorContains(t, err, test.wantError, test.msg)
+		} else {
+			assert.NoError(t, err, test.msg)
+		}
+
+		assert.Equal(t, test.wantMutated, !assert.ObjectsAreEqual(input, result), test.msg)
+		assert.Equal(t, test.wantWarnings, admission.WarningsFromContext(ctx), test.msg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	config := &limitrange.Config{
+		HasMax: true, Max: resource.MustParse("1Gi"),
+		HasMaxLimitRequestRatio: true, MaxLimitRequestRatio: resource.MustParse("2"),
+		EnforceMinMax: true,
+	}
+
+	tests := []struct {
+		msg          string
+		opts         []OptionsFunc
+		config       *limitrange.Config
+		spec         corev1.PodSpec
+		wantError    bool
+		wantWarnings int
+	}{
+		{
+			msg:    "Compliant resources",
+			config: config,
+			spec:   corev1.PodSpec{Containers: []corev1.Container{memoryContainer("a", "256Mi", "512Mi")}},
+		},
+		{
+			msg:       "Missing values are not set",
+			config:    config,
+			spec:      corev1.PodSpec{Containers: []corev1.Container{memoryContainer("a", "256Mi", "")}},
+			wantError: true,
+		},
+		{
+			msg:       "Limit above Max and ratio",
+			config:    config,
+			spec:      corev1.PodSpec{Containers: []corev1.Container{memoryContainer("a", "256Mi", "2Gi")}},
+			wantError: true,
+		},
+		{
+			msg:  "Skipped init containers are not validated",
+			opts: []OptionsFunc{WithInitContainerPolicy(InitContainerPolicySkip)},
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{memoryContainer("init", "", "")},
+				Containers:     []corev1.Container{memoryContainer("a", "256Mi", "512Mi")},
+			},
+			config: config,
+		},
+		{
+			msg:          "Warn enforcement mode",
+			opts:         []OptionsFunc{WithEnforcementMode(limitrange.EnforcementModeWarn)},
+			config:       config,
+			spec:         corev1.PodSpec{Containers: []corev1.Container{memoryContainer("a", "256Mi", "2Gi")}},
+			wantWarnings: 2,
+		},
+		{
+			msg:       "Missing config",
+			spec:      corev1.PodSpec{Containers: []corev1.Container{memoryContainer("a", "256Mi", "512Mi")}},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		ctx := admission.WithWarnings(context.Background())
+		err := NewPodTemplateSpec(test.opts...).Validate(ctx, test.spec, test.config)
+		assert.Equal(t, test.wantError, err != nil, test.msg)
+		assert.Len(t, admission.WarningsFromContext(ctx), test.wantWarnings, test.msg)
+	}
+}
+
+func TestMutatePodResources(t *testing.T) {
+	t.Parallel()
+
+	config := &limitrange.Config{
+		HasDefaultRequest: true, DefaultRequest: resource.MustParse("64Mi"),
+		HasDefaultLimit: true, DefaultLimit: resource.MustParse("128Mi"),
+		HasPodMax: true, PodMax: resource.MustParse("2Gi"),
+	}
+	pts := NewPodTemplateSpec()
+
+	podResources := func(request, limit string) *corev1.ResourceRequirements {
+		r := memoryContainer("", request, limit).Resources
+		return &r
+	}
+
+	tests := []struct {
+		msg       string
+		spec      corev1.PodSpec
+		want      []corev1.Container
+		wantError bool
+	}{
+		{
+			msg: "Container limits are not defaulted under a pod-level limit",
+			spec: corev1.PodSpec{
+				Resources:  podResources("", "1Gi"),
+				Containers: []corev1.Container{memoryContainer("a", "", ""), memoryContainer("b", "256Mi", "")},
+			},
+			want: []corev1.Container{memoryContainer("a", "64Mi", ""), memoryContainer("b", "256Mi", "")},
+		},
+		{
+			msg: "Container values are not defaulted under pod-level resources",
+			spec: corev1.PodSpec{
+				Resources:  podResources("512Mi", "1Gi"),
+				Containers: []corev1.Container{memoryContainer("a", "", "")},
+			},
+			want: []corev1.Container{memoryContainer("a", "", "")},
+		},
+		{
+			msg: "Container limit above the pod-level limit",
+			spec: corev1.PodSpec{
+				Resources:  podResources("", "1Gi"),
+				Containers: []corev1.Container{memoryContainer("a", "1Gi", "1536Mi")},
+			},
+			wantError: true,
+		},
+		{
+			msg: "Container requests exceed the pod-level request",
+			spec: corev1.PodSpec{
+				Resources:  podResources("256Mi", "1Gi"),
+				Containers: []corev1.Container{memoryContainer("a", "200Mi", ""), memoryContainer("b", "100Mi", "")},
+			},
+			wantError: true,
+		},
+		{
+			msg: "Pod-level limit above the Pod Max",
+			spec: corev1.PodSpec{
+				Resources:  podResources("", "4Gi"),
+				Containers: []corev1.Container{memoryContainer("a", "256Mi", "")},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := pts.Mutate(context.Background(), corev1.PodTemplateSpec{Spec: test.spec}, config)
+		assert.Equal(t, test.wantError, err != nil, test.msg)
+		if test.wantError {
+			continue
+		}
+
+		for idx, want := range test.want {
+			got := result.Spec.Containers[idx].Resources
+			assert.Equal(t, want.Resources.Requests.Memory().String(), got.Requests.Memory().String(), test.msg)
+			assert.Equal(t, want.Resources.Limits.Memory().String(), got.Limits.Memory().String(), test.msg)
+		}
+	}
+}
+
+func TestMutateRuntimeClassOverhead(t *testing.T) {
+	t.Parallel()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NoError(t, indexer.Add(&nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "kata"},
+		Handler:    "kata",
+		Overhead:   &nodev1.Overhead{PodFixed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}},
+	}))
+
+	config := &limitrange.Config{HasPodMax: true, PodMax: resource.MustParse("1Gi")}
+	pts := NewPodTemplateSpec(WithRuntimeClassLister(nodev1Listers.NewRuntimeClassLister(indexer)))
+
+	runtimeClass := func(name string) *string {
+		return &name
+	}
+
+	tests := []struct {
+		msg         string
+		spec        corev1.PodSpec
+		wantMessage string
+	}{
+		{
+			msg:  "Without a RuntimeClass",
+			spec: corev1.PodSpec{Containers: []corev1.Container{memoryContainer("a", "1Gi", "1Gi")}},
+		},
+		{
+			msg: "RuntimeClass overhead pushes the pod above the Pod Max",
+			spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("kata"),
+				Containers:       []corev1.Container{memoryContainer("a", "1Gi", "1Gi")},
+			},
+			wantMessage: "pod: effective memory limit (1280Mi including the RuntimeClass overhead of 256Mi) exceeds the Pod Max (1Gi)",
+		},
+		{
+			msg: "Overhead set on admission",
+			spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("unknown"),
+				Overhead:         corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+				Containers:       []corev1.Container{memoryContainer("a", "1Gi", "1Gi")},
+			},
+			wantMessage: "pod: effective memory limit (1152Mi including the RuntimeClass overhead of 128Mi) exceeds the Pod Max (1Gi)",
+		},
+		{
+			msg: "Unknown RuntimeClass",
+			spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("unknown"),
+				Containers:       []corev1.Container{memoryContainer("a", "1Gi", "1Gi")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := pts.Mutate(context.Background(), corev1.PodTemplateSpec{Spec: test.spec}, config)
+		if test.wantMessage == "" {
+			assert.NoError(t, err, test.msg)
+			continue
+		}
+
+		if assert.Error(t, err, test.msg) {
+			assert.Contains(t, err.Error(), test.wantMessage, test.msg)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package mutators
+
+import (
+	"context"
+
+	"github.com/kanopy-platform/hedgetrimmer/pkg/limitrange"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type containerSuggestion struct {
+	Name      string                      `json:"name"`
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+type podSpecSuggestion struct {
+	InitContainers []containerSuggestion `json:"initContainers,omitempty"`
+	Containers     []containerSuggestion `json:"containers,omitempty"`
+}
+
+func (s podSpecSuggestion) empty() bool {
+	return len(s.InitContainers) == 0 && len(s.Containers) == 0
+}
+
+// suggestionsFor returns the memory resources of every container in mutated which differ from original.
+func suggestionsFor(original, mutated corev1.PodSpec) podSpecSuggestion {
+	return podSpecSuggestion{
+		InitContainers: changedContainers(original.InitContainers, mutated.InitContainers),
+		Containers:     changedContainers(original.Containers, mutated.Containers),
+	}
+}
+
+func changedContainers(original, mutated []corev1.Container) []containerSuggestion {
+	var suggestions []containerSuggestion
+
+	for idx := range mutated {
+		if idx >= len(original) {
+			break
+		}
+
+		if original[idx].Resources.Requests.Memory().Equal(*mutated[idx].Resources.Requests.Memory()) &&
+			original[idx].Resources.Limits.Memory().Equal(*mutated[idx].Resources.Limits.Memory()) {
+			continue
+		}
+
+		suggestion := containerSuggestion{Name: mutated[idx].Name}
+		if request := mutated[idx].Resources.Requests.Memory(); !request.IsZero() {
+			suggestion.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: *request}
+		}
+		if limit := mutated[idx].Resources.Limits.Memory(); !limit.IsZero() {
+			suggestion.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: *limit}
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions
+}
+
+// YAML renders the suggestion as a snippet which can be pasted into a pod spec.
+func (s podSpecSuggestion) YAML() (string, error) {
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// warnings renders one single line message per container.
+func (s podSpecSuggestion) warnings(ctx context.Context, messages *Messages, cfg *limitrange.Config) []string {
+	var warnings []string
+
+	format := func(c containerSuggestion, init bool) string {
+		data := messageData(ctx, corev1.Container{Name: c.Name, Resources: c.Resources}, cfg)
+		data.InitContainer = init
+		return messages.Render(MessageSuggestion, data)
+	}
+
+	for _, c := range s.InitContainers {
+		warnings = append(warnings, format(c, true))
+	}
+
+	for _, c := range s.Containers {
+		warnings = append(warnings, format(c, false))
+	}
+
+	return warnings
+}
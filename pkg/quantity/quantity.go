@@ -7,6 +7,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
+// ScaleMicro is the scale memory ratios are computed with, so they are rounded the same way everywhere.
+const ScaleMicro inf.Scale = 6 // 10^-6
+
 func Add(x resource.Quantity, y resource.Quantity) resource.Quantity {
 	result := x.DeepCopy()
 	result.Add(y)